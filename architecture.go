@@ -1,34 +1,91 @@
 package main
 
-import "fmt"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+const WORDSIZE = 5
+
+// ByteSize is the number of values a byte holds. Knuth leaves it open,
+// a correct MIX program works whether a byte holds 64 or 100 values.
+type ByteSize Word
 
 const (
-	WORDSIZE = 5
-	BYTESIZE = 6
+	Binary  ByteSize = 64
+	Decimal ByteSize = 100
 )
 
-type Word int32
+// Word holds a MIX word as a two's complement integer, except −0,
+// which is kept as negZero since two's complement has no −0.
+type Word int64
+
+// negZero is −0: the sign bit with no magnitude.
+const negZero Word = -1 << 63
+
 type bitslice struct {
 	w, start, len Word // start = actual start (w/ sign), len = length of data
+	size          ByteSize
 }
 
-// to negate, just do -composeWord(...)
-func composeWord(b1, b2, b3, b4, b5 Word) (w Word) {
-	w = (b1&63)<<24 | (b2&63)<<18 | (b3&63)<<12 | (b4&63)<<6 | (b5 & 63)
+// pow returns b^n.
+func (b ByteSize) pow(n Word) Word {
+	p := Word(1)
+	for ; 0 < n; n-- {
+		p *= Word(b)
+	}
+	return p
+}
+
+// max returns the largest magnitude a Word can hold, b^5 - 1.
+func (b ByteSize) max() Word {
+	return b.pow(WORDSIZE) - 1
+}
+
+// word composes a Word from five bytes.
+func (b ByteSize) word(b1, b2, b3, b4, b5 Word) (w Word) {
+	for _, v := range [WORDSIZE]Word{b1, b2, b3, b4, b5} {
+		w = w*Word(b) + v%Word(b)
+	}
 	return w
 }
 
-func (w Word) view() string {
+// field returns bytes L through R of data, 1 <= L.
+func (b ByteSize) field(data, L, R Word) Word {
+	return data / b.pow(WORDSIZE-R) % b.pow(R-L+1)
+}
+
+func (b ByteSize) view(w Word) string {
 	sign, data := "+", w.data()
 	if w < 0 {
 		sign = "-"
 	}
 	return fmt.Sprintf(
-		"%s %v %v %v %v %v",
-		sign, data>>24, data>>18&63, data>>12&63, data>>6&63, data&63,
+		"%s %v %v %v %v %v", sign,
+		b.field(data, 1, 1), b.field(data, 2, 2), b.field(data, 3, 3), b.field(data, 4, 4), b.field(data, 5, 5),
 	)
 }
 
+// composeWord, view and slice work with binary bytes,
+// for tests and other places without a machine at hand.
+
+// to negate, just do -composeWord(...)
+func composeWord(b1, b2, b3, b4, b5 Word) Word {
+	return Binary.word(b1, b2, b3, b4, b5)
+}
+
+func (w Word) view() string {
+	return Binary.view(w)
+}
+
+func (w Word) slice(L, R Word) *bitslice {
+	return Binary.slice(w, L, R)
+}
+
 func (w Word) sign() Word {
 	if w < 0 {
 		return -1
@@ -36,54 +93,105 @@ func (w Word) sign() Word {
 	return 1
 }
 
-func (w Word) data() (v Word) {
-	v = w
+// signed returns data (a magnitude) with sign,
+// giving negZero for a negative sign and no data.
+func signed(sign, data Word) Word {
+	if sign < 0 {
+		if data == 0 {
+			return negZero
+		}
+		return -data
+	}
+	return data
+}
+
+// neg returns w with its sign flipped, so +0 and −0 swap.
+func (w Word) neg() Word {
+	return signed(-w.sign(), w.data())
+}
+
+// value returns w as a number to compute or compare with, +0 == −0.
+func (w Word) value() Word {
+	if w == negZero {
+		return 0
+	}
+	return w
+}
+
+// data returns the magnitude of w.
+func (w Word) data() Word {
 	if w < 0 {
-		v = -v
+		return -w.value()
+	}
+	return w
+}
+
+// add returns left + right with its magnitude wrapped to five bytes,
+// keeping the sign, and whether the magnitude needed a sixth byte.
+// A zero sum keeps the sign of left.
+func (b ByteSize) add(left, right Word) (sum Word, overflowed bool) {
+	sum = left.value() + right.value()
+	if sum == 0 {
+		return signed(left.sign(), 0), false
 	}
-	return v & 0x3FFFFFFF // last 30 bits used as data, 6 bits/Byte
+	mag, max := sum.data(), b.max()
+	return signed(sum.sign(), mag%(max+1)), max < mag
 }
 
-func (left Word) add(right Word) (sum Word, overflowed bool) {
-	return left + right, left < left+right
+// mul returns the magnitude of u × v as two words, high and low.
+func (b ByteSize) mul(u, v Word) (hi, lo Word) {
+	h, l := bits.Mul64(uint64(u.data()), uint64(v.data()))
+	q, r := bits.Div64(h, l, uint64(b.max()+1))
+	return Word(q), Word(r)
 }
 
-// really only bitmask for data
-func bitmask(L, R Word) (mask Word) {
+// get returns bytes L through R of w, with the sign of w when L is 0
+// and positive otherwise: slice(w, L, R).w without allocating.
+func (b ByteSize) get(w, L, R Word) Word {
 	if L == 0 {
-		L = 1
+		return signed(w.sign(), b.field(w.data(), 1, R))
 	}
-	var bytePos Word = 0x3F << ((WORDSIZE - R) * BYTESIZE)
-	for i := R; L <= i; i-- {
-		mask |= bytePos
-		bytePos <<= BYTESIZE
+	return b.field(w.data(), L, R)
+}
+
+// set returns w with bytes L through R replaced by the last bytes of v,
+// and with the sign of v when L is 0, the way STA stores rA.
+func (b ByteSize) set(w, L, R, v Word) Word {
+	sign := w.sign()
+	if L == 0 {
+		sign, L = v.sign(), 1
 	}
-	return mask
+	pos, span := b.pow(WORDSIZE-R), b.pow(R-L+1)
+	data := w.data()
+	data += (v.data()%span - data/pos%span) * pos
+	return signed(sign, data)
 }
 
 // slice returns the Word in [L:R].
 // positive if sign isn't included in the slice.
-func (w Word) slice(L, R Word) (s *bitslice) {
-	v := w.data() & bitmask(L, R) >> ((WORDSIZE - R) * BYTESIZE)
+func (b ByteSize) slice(w, L, R Word) (s *bitslice) {
 	dataStart := L
 	if L == 0 {
-		v *= w.sign()
 		dataStart = 1
 	}
-	return &bitslice{v, L, R - dataStart + 1}
+	v := b.field(w.data(), dataStart, R)
+	if L == 0 {
+		v = signed(w.sign(), v)
+	}
+	return &bitslice{v, L, R - dataStart + 1, b}
 }
 
 func (dst *bitslice) copy(src *bitslice) *bitslice {
-	srcData, copyAmt := src.w.data(), src.len
+	copyAmt := src.len
 	if dst.len < copyAmt {
 		copyAmt = dst.len
 	}
-	mask := bitmask(WORDSIZE-copyAmt+1, WORDSIZE)
-	data := dst.w.data()&(mask^0x7FFFFFFF) | (srcData & mask)
+	span, data := dst.size.pow(copyAmt), dst.w.data()
+	data += src.w.data()%span - data%span
 	if src.start == 0 {
-		dst.w = data * src.w.sign()
+		dst.w = signed(src.w.sign(), data)
 	} else {
-		dst.w = data * dst.w.sign()
+		dst.w = signed(dst.w.sign(), data)
 	}
 	return dst
 }
@@ -94,9 +202,10 @@ func (b *bitslice) apply(w Word) Word {
 		sign = b.w.sign()
 		L = 1
 	}
-	shiftAmt := (WORDSIZE - b.len + 1 - L) * BYTESIZE
-	mask, toPos := bitmask(L, L+b.len-1), b.w.data()<<shiftAmt
-	return sign * (w.data()&(mask^0x3FFFFFFF) | toPos)
+	pos, span := b.size.pow(WORDSIZE-b.len+1-L), b.size.pow(b.len)
+	data := w.data()
+	data += (b.w.data()%span - data/pos%span) * pos
+	return signed(sign, data)
 }
 
 const (
@@ -117,20 +226,101 @@ type Arch struct {
 	R                   []*bitslice
 	Mem                 []Word
 	PC                  Word // program counter
+	State               State
+	Time                Word // elapsed time in units u
+	Executed            Word // instructions executed
 	OverflowToggle      bool
 	ComparisonIndicator struct {
 		Less, Equal, Greater bool
 	}
+	Control       bool // in control state, see interrupt.go
+	b             ByteSize
+	floatingPoint bool
+	binaryExt     bool
+	interrupts    bool
+	strict        bool
+	memSize       Word   // see WithMemorySize
+	pending       bool   // an interrupt is waiting for normal state
+	control       []Word // locations -1, -2, ... at index 1, 2, ...
+	units         map[Word]*unit
+	historySize   int
+	history       []*effect
+	effect        *effect // of the step running, when keeping history or tracing
+	trace         *json.Encoder
+	traceErr      error
+	profile       map[Word]Count
+	decoded       []decoded // by location, see fetch
+	breakpoints   []breakpoint
+	watches       []watchpoint
+	points        int   // breakpoints and watchpoints set so far, for IDs
+	stop          *Stop // the first watchpoint hit by the step running
+	maxExecuted   Word  // see WithBudget
+	maxTime       Word
+
+	mu     sync.Mutex // held while executing, see Run
+	pause  atomic.Bool
+	resume chan struct{} // closed by Resume
 }
 
+// Read returns the cell at address, negative addresses being
+// the control state locations of the interrupt facility.
+// Instructions read their operands with it, see WatchMemory.
 func (m *Arch) Read(address Word) Word {
-	return m.Mem[address]
+	v := m.peek(address)
+	if 0 < len(m.watches) {
+		m.watched(address, OnRead, v, v)
+	}
+	return v
 }
 
+// Write sets the cell at address, as instructions store to memory.
 func (m *Arch) Write(address, data Word) {
+	old := m.peek(address)
+	if m.effect != nil {
+		m.effect.cells = append(m.effect.cells, cell{address, old})
+	}
+	if 0 < len(m.watches) {
+		m.watched(address, OnWrite, old, data)
+	}
+	m.poke(address, data)
+}
+
+// peek and poke read and set a cell for the machine itself,
+// fetching instructions or undoing steps, unseen by watchpoints.
+
+func (m *Arch) peek(address Word) Word {
+	if address < 0 {
+		return m.control[-address]
+	}
+	return m.Mem[address]
+}
+
+func (m *Arch) poke(address, data Word) {
+	if address < 0 {
+		m.control[-address] = data
+		return
+	}
 	m.Mem[address] = data
 }
 
+// reg and setReg read and set register r as instructions do,
+// seen by register watchpoints, see WatchRegister.
+
+func (m *Arch) reg(r Word) Word {
+	v := m.R[r].w
+	if 0 < len(m.watches) {
+		m.watchedRegister(r, OnRead, v, v)
+	}
+	return v
+}
+
+func (m *Arch) setReg(r, v Word) {
+	if 0 < len(m.watches) {
+		m.watchedRegister(r, OnWrite, m.R[r].w, v)
+	}
+	m.R[r].w = v
+}
+
 func (m *Arch) SetComparisons(lt, eq, gt bool) {
 	m.ComparisonIndicator.Less = lt
 	m.ComparisonIndicator.Equal = eq
@@ -141,20 +331,89 @@ func (m *Arch) Comparisons() (bool, bool, bool) {
 	return m.ComparisonIndicator.Less, m.ComparisonIndicator.Equal, m.ComparisonIndicator.Greater
 }
 
-// NewMachine creates a new instance of Arch
-func NewMachine() *Arch {
+// Option configures a machine made by NewMachine.
+type Option func(*Arch)
+
+// WithFloatingPoint installs the floating point attachment,
+// FADD, FSUB, FMUL, FDIV, FLOT, FIX and FCMP.
+func WithFloatingPoint() Option {
+	return func(m *Arch) { m.floatingPoint = true }
+}
+
+// WithBinaryExtensions installs AND, OR, XOR, SLB, SRB, JAE, JAO, JXE
+// and JXO. They only run with binary bytes.
+func WithBinaryExtensions() Option {
+	return func(m *Arch) { m.binaryExt = true }
+}
+
+// WithInterrupts installs the interrupt facility,
+// control state memory and the INT instruction.
+func WithInterrupts() Option {
+	return func(m *Arch) { m.interrupts = true }
+}
+
+// ErrMemorySize is the panic of NewMachine given a memory size
+// its two byte addresses can't reach all of.
+var ErrMemorySize = errors.New("machine: memory size outside of [1, b^2]")
+
+// WithMemorySize gives the machine size words of memory instead of 4000,
+// at most b^2 words, what an index register or a saved PC can hold.
+func WithMemorySize(size Word) Option {
+	return func(m *Arch) { m.memSize = size }
+}
+
+// WithDevice attaches d as unit u. Each operation keeps the unit
+// busy for latency units of time after the instruction.
+func WithDevice(u Word, d Device, latency Word) Option {
+	return func(m *Arch) { m.units[u] = &unit{dev: d, latency: latency} }
+}
+
+// WithStrict runs only what Knuth's MIX defines: the binary extensions
+// are refused, and so are results MIX leaves undefined, such as loading,
+// increasing or entering an index register past two bytes, which is
+// otherwise truncated.
+func WithStrict() Option {
+	return func(m *Arch) { m.strict = true }
+}
+
+// WithByteSize sets how many values a byte holds, Binary (the default) or Decimal.
+func WithByteSize(b ByteSize) Option {
+	return func(m *Arch) { m.b = b }
+}
+
+// NewMachine creates a new instance of Arch.
+// It panics with ErrMemorySize for a memory size out of range.
+func NewMachine(opts ...Option) *Arch {
 	machine := &Arch{
-		R:   make([]*bitslice, 9),
-		Mem: make([]Word, 4000),
+		R: make([]*bitslice, 9),
 		ComparisonIndicator: struct {
 			Less, Equal, Greater bool
 		}{},
+		b:       Binary,
+		memSize: 4000,
+		units:   make(map[Word]*unit),
+	}
+	for _, opt := range opts {
+		opt(machine)
+	}
+	if machine.memSize < 1 || machine.b.pow(2) < machine.memSize {
+		panic(ErrMemorySize)
+	}
+	machine.Mem = make([]Word, machine.memSize)
+	if machine.interrupts {
+		machine.control = make([]Word, len(machine.Mem))
+	}
+	for _, u := range machine.units {
+		if a, ok := u.dev.(attacher); ok {
+			a.attach(machine)
+		}
 	}
+	machine.decoded = make([]decoded, len(machine.Mem))
 	for i := range machine.R {
 		if i == A || i == X {
-			machine.R[i] = Word(0).slice(0, 5)
+			machine.R[i] = machine.b.slice(0, 0, 5)
 		} else {
-			machine.R[i] = Word(0).slice(0, 2)
+			machine.R[i] = machine.b.slice(0, 0, 2)
 		}
 	}
 	return machine
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -24,3 +25,133 @@ func TestBitslice(t *testing.T) {
 		t.Errorf("\nWant:%s\nGot:%s\n", want.view(), s1.w.view())
 	}
 }
+
+func TestAdd(t *testing.T) {
+	max := composeWord(63, 63, 63, 63, 63)
+	tests := []struct {
+		Left, Right, Want Word
+		Overflowed        bool
+	}{
+		{100, -30, 70, false},
+		{max, 0, max, false},
+		{max - 1, 1, max, false},
+		{max, 1, 0, true},
+		{-max, -1, negZero, true},
+		{max, max, max - 1, true},
+		{-max, -max, -(max - 1), true},
+		{max, -max, 0, false},
+		{-max, max, negZero, false},
+		{negZero, 0, negZero, false},
+		{0, negZero, 0, false},
+		{negZero, -3, -3, false},
+		{composeWord(32, 0, 0, 0, 0), composeWord(32, 0, 0, 0, 5), 5, true},
+	}
+	for _, test := range tests {
+		sum, overflowed := Binary.add(test.Left, test.Right)
+		if sum != test.Want || overflowed != test.Overflowed {
+			t.Errorf("%v + %v: %s\nOverflow want %v, got %v", test.Left, test.Right, wordDiff(test.Want, sum), test.Overflowed, overflowed)
+		}
+	}
+}
+
+func TestWordNegZero(t *testing.T) {
+	if negZero.value() != 0 || negZero.data() != 0 || negZero.sign() != -1 {
+		t.Error("unexpected -0")
+	}
+	if Word(0).neg() != negZero || negZero.neg() != 0 {
+		t.Error("neg doesn't swap +0 and -0")
+	}
+	if negZero.view() != "- 0 0 0 0 0" {
+		t.Error(negZero.view())
+	}
+	w := -composeWord(0, 0, 0, 4, 5)
+	if s := w.slice(0, 3).w; s != negZero {
+		t.Error(wordDiff(negZero, s))
+	}
+	if s := w.slice(0, 0).copy(negZero.slice(0, 5)).apply(composeWord(1, 2, 3, 4, 5)); s != -composeWord(1, 2, 3, 4, 5) {
+		t.Error(wordDiff(-composeWord(1, 2, 3, 4, 5), s))
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	w := -Decimal.word(1, 2, 3, 4, 5)
+	if w != -102030405 || Decimal.view(w) != "- 1 2 3 4 5" {
+		t.Error(Decimal.view(w))
+	}
+	if s := Decimal.slice(w, 0, 2).w; s != -102 {
+		t.Errorf("want -102, got %v", s)
+	}
+	if s := Decimal.slice(0, 4, 5).copy(Decimal.slice(w, 4, 5)).apply(Decimal.word(9, 9, 9, 9, 9)); s != Decimal.word(9, 9, 9, 4, 5) {
+		t.Error(Decimal.view(s))
+	}
+	max := Decimal.max()
+	if max != 9999999999 {
+		t.Errorf("want 10^10 - 1, got %v", max)
+	}
+	if sum, overflowed := Decimal.add(max, 2); sum != 1 || !overflowed {
+		t.Errorf("want 1 and overflow, got %v and %v", sum, overflowed)
+	}
+	if hi, lo := Decimal.mul(1000000000, -100); hi != 10 || lo != 0 {
+		t.Errorf("want 10 and 0, got %v and %v", hi, lo)
+	}
+	if inst := Decimal.inst(1234, 5, 6, 7); Decimal.a(inst) != 1234 || Decimal.i(inst) != 5 || Decimal.f(inst) != 6 || Decimal.c(inst) != 7 {
+		t.Error(Decimal.view(inst))
+	}
+}
+
+func TestOptions(t *testing.T) {
+	m := NewMachine(WithMemorySize(100))
+	if len(m.Mem) != 100 {
+		t.Errorf("want 100 words, got %v", len(m.Mem))
+	}
+	if err := m.Exec(composeInst(100, 0, 5, C_LD)); !errors.Is(err, ErrAddress) { // LDA 100
+		t.Errorf("want %v, got %v", ErrAddress, err)
+	}
+	for _, test := range []struct {
+		b    ByteSize
+		size Word
+		ok   bool
+	}{
+		{Binary, 4096, true}, {Decimal, 10000, true},
+		{Binary, 4097, false}, {Decimal, 10001, false}, {Binary, 0, false}, {Binary, -1, false},
+	} {
+		func() {
+			defer func() {
+				if r := recover(); test.ok == (r != nil) || r != nil && r != ErrMemorySize {
+					t.Errorf("%d words of %d: want ok %v, got %v", test.size, test.b, test.ok, r)
+				}
+			}()
+			NewMachine(WithMemorySize(test.size), WithByteSize(test.b))
+		}()
+	}
+	for _, strict := range []bool{false, true} {
+		opts := []Option{WithMemorySize(4096)}
+		if strict {
+			opts = append(opts, WithStrict())
+		}
+		m := NewMachine(opts...)
+		m.R[I1].w = 4094
+		err := m.Exec(composeInst(0, 0, 2, C_MOVE)) // MOVE 0(2)
+		if strict && (!errors.Is(err, ErrIndexOverflow) || m.R[I1].w != 4094) || !strict && (err != nil || m.R[I1].w != 0) {
+			t.Errorf("strict %v: MOVE to the end of memory left rI1 %v, %v", strict, m.R[I1].w, err)
+		}
+	}
+
+	m = NewMachine(WithStrict(), WithBinaryExtensions())
+	if err := m.Exec(composeInst(1000, 0, 10, C_SPECIAL)); !errors.Is(err, ErrNoBinary) { // AND 1000
+		t.Errorf("strict: want %v, got %v", ErrNoBinary, err)
+	}
+	m.Write(1000, 5000)
+	if err := m.Exec(composeInst(1000, 0, 5, C_LD+I1)); !errors.Is(err, ErrIndexOverflow) { // LD1 1000
+		t.Errorf("strict: want %v, got %v", ErrIndexOverflow, err)
+	}
+	m.R[I1].w = 100
+	if err := m.Exec(composeInst(4000, 0, 0, C_ADDR_TRANSFER+I1)); !errors.Is(err, ErrIndexOverflow) || m.R[I1].w != 100 { // INC1 4000
+		t.Errorf("strict: want %v and rI1 100, got %v and %v", ErrIndexOverflow, err, m.R[I1].w)
+	}
+	m = NewMachine()
+	m.Write(1000, 5000)
+	if err := m.Exec(composeInst(1000, 0, 5, C_LD+I1)); err != nil || m.R[I1].w != 5000%4096 { // LD1 1000
+		t.Errorf("want rI1 %v, got %v, %v", 5000%4096, m.R[I1].w, err)
+	}
+}
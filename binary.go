@@ -0,0 +1,43 @@
+package main
+
+import "errors"
+
+// Binary extensions from Knuth's later MIX revision, for machines with
+// binary bytes only: AND, OR and XOR on rA (C=5, F=10-12), the binary
+// shifts SLB and SRB of rAX (C=6, F=6-7), and the even/odd jumps
+// JAE, JAO (C=40, F=6-7) and JXE, JXO (C=47, F=6-7).
+
+var ErrNoBinary = errors.New("exec: binary extensions not installed")
+
+// Bitwise sets the magnitude of rA to its AND (F=10), OR (F=11)
+// or XOR (F=12) with the magnitude of the cell at M.
+// The sign of rA is unchanged.
+func (m *Arch) Bitwise(inst, M Word) {
+	a, v := m.reg(A).data(), m.Read(M).data()
+	switch m.b.f(inst) {
+	case 10:
+		a &= v
+	case 11:
+		a |= v
+	case 12:
+		a ^= v
+	}
+	m.setReg(A, signed(m.reg(A).sign(), a))
+}
+
+// shiftBits shifts the data of rAX left (SLB, F=6) or right (SRB, F=7)
+// by M bits, shifting in zeros. Signs are unchanged.
+func (m *Arch) shiftBits(F, M Word) {
+	const n = WORDSIZE * 6 // bits in a word of binary bytes
+	ax := uint64(m.reg(A).data()<<n | m.reg(X).data())
+	switch {
+	case 2*n <= M:
+		ax = 0
+	case F == 6:
+		ax = ax << uint(M) & (1<<(2*n) - 1)
+	default:
+		ax >>= uint(M)
+	}
+	m.setReg(A, signed(m.reg(A).sign(), Word(ax>>n)))
+	m.setReg(X, signed(m.reg(X).sign(), Word(ax&(1<<n-1))))
+}
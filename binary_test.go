@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBinaryExt(t *testing.T) {
+	tests := []struct {
+		A, X      Word
+		Inst      Word
+		WantA     Word
+		WantX, PC Word
+	}{
+		{-0x3f0f, 0, composeInst(1000, 0, 10, C_SPECIAL), -0x300a, 0, 101},                // AND 1000
+		{0x3f0f, 0, composeInst(1000, 0, 11, C_SPECIAL), 0x3f3f, 0, 101},                  // OR 1000
+		{0x3f0f, 0, composeInst(1000, 0, 12, C_SPECIAL), 0x0f35, 0, 101},                  // XOR 1000
+		{1, -1 << 29, composeInst(1, 0, 6, C_SHIFT), 3, negZero, 101},                     // SLB 1
+		{-1, 3, composeInst(1, 0, 7, C_SHIFT), negZero, composeWord(32, 0, 0, 0, 1), 101}, // SRB 1
+		{1, 2, composeInst(60, 0, 6, C_SHIFT), 0, 0, 101},                                 // SLB 60
+		{-4, 0, composeInst(1000, 0, 6, C_JREG), -4, 0, 1000},                             // JAE 1000
+		{-4, 0, composeInst(1000, 0, 7, C_JREG), -4, 0, 101},                              // JAO 1000
+		{0, 7, composeInst(1000, 0, 7, C_JREG+X), 0, 7, 1000},                             // JXO 1000
+		{0, 7, composeInst(1000, 0, 6, C_JREG+X), 0, 7, 101},                              // JXE 1000
+	}
+	for _, test := range tests {
+		m := NewMachine(WithBinaryExtensions())
+		m.R[A].w, m.R[X].w, m.PC = test.A, test.X, 101
+		m.Write(1000, 0x303a)
+		if err := m.Exec(test.Inst); err != nil {
+			t.Fatal(err)
+		}
+		if m.R[A].w != test.WantA || m.R[X].w != test.WantX || m.PC != test.PC {
+			t.Errorf("\n%s\nrA:%s\nrX:%s\nPC want %v, got %v", test.Inst.instView(),
+				wordDiff(test.WantA, m.R[A].w), wordDiff(test.WantX, m.R[X].w), test.PC, m.PC)
+		}
+	}
+
+	for _, m := range []*Arch{NewMachine(), NewMachine(WithBinaryExtensions(), WithByteSize(Decimal))} {
+		err := m.Exec(m.b.inst(1000, 0, 10, C_SPECIAL)) // AND 1000
+		if !errors.Is(err, ErrNoBinary) {
+			t.Errorf("byte size %d: want %v, got %v", m.b, ErrNoBinary, err)
+		}
+	}
+}
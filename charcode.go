@@ -0,0 +1,68 @@
+package main
+
+import "errors"
+
+// mixChars lists the MIX characters in order of their codes (TAOCP 1.3.1),
+// so the code of a character is its index.
+var mixChars = []rune(" ABCDEFGHIΔJKLMNOPQRΣΠSTUVWXYZ0123456789.,()+-*/=$<>@;:'")
+
+var charCodes = func() map[rune]Word {
+	codes := make(map[rune]Word, len(mixChars))
+	for code, c := range mixChars {
+		codes[c] = Word(code)
+	}
+	return codes
+}()
+
+var (
+	ErrNoChar = errors.New("charcode: no MIX character for rune")
+	ErrNoCode = errors.New("charcode: no MIX character for code")
+)
+
+// encodeChar returns the MIX character code of c.
+func encodeChar(c rune) (Word, error) {
+	code, ok := charCodes[c]
+	if !ok {
+		return 0, ErrNoChar
+	}
+	return code, nil
+}
+
+// decodeChar returns the character with MIX character code code.
+func decodeChar(code Word) (rune, error) {
+	if code < 0 || Word(len(mixChars)) <= code {
+		return 0, ErrNoCode
+	}
+	return mixChars[code], nil
+}
+
+// encodeWord packs up to 5 characters of s into a Word, padding with spaces.
+func (b ByteSize) encodeWord(s string) (w Word, err error) {
+	chars := []rune(s)
+	if WORDSIZE < len(chars) {
+		return 0, ErrNoChar
+	}
+	for i := 0; i < WORDSIZE; i++ {
+		var code Word
+		if i < len(chars) {
+			if code, err = encodeChar(chars[i]); err != nil {
+				return 0, err
+			}
+		}
+		w = w*Word(b) + code
+	}
+	return w, nil
+}
+
+// decodeWord unpacks the 5 characters in the bytes of w, ignoring its sign.
+func (b ByteSize) decodeWord(w Word) (string, error) {
+	chars := make([]rune, WORDSIZE)
+	for i := range chars {
+		c, err := decodeChar(b.field(w.data(), Word(i+1), Word(i+1)))
+		if err != nil {
+			return "", err
+		}
+		chars[i] = c
+	}
+	return string(chars), nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCharCode(t *testing.T) {
+	tests := []struct {
+		Chars string
+		Want  Word
+		Err   error
+	}{
+		{"     ", composeWord(0, 0, 0, 0, 0), nil},
+		{"AΔJΣΠ", composeWord(1, 10, 11, 20, 21), nil},
+		{"09.'", composeWord(30, 39, 40, 55, 0), nil},
+		{"a", 0, ErrNoChar},
+		{"TOOLONG", 0, ErrNoChar},
+	}
+	for _, test := range tests {
+		w, err := Binary.encodeWord(test.Chars)
+		if w != test.Want || err != test.Err {
+			t.Errorf("%q: %s\n%v | %v", test.Chars, wordDiff(test.Want, w), test.Err, err)
+		}
+	}
+	if s, err := Binary.decodeWord(composeWord(8, 5, 13, 13, 16)); s != "HELLO" || err != nil {
+		t.Errorf("want HELLO, got %q, %v", s, err)
+	}
+	if s, err := Decimal.decodeWord(Decimal.word(8, 5, 13, 13, 16)); s != "HELLO" || err != nil {
+		t.Errorf("want HELLO, got %q, %v", s, err)
+	}
+	if _, err := Binary.decodeWord(composeWord(56, 0, 0, 0, 0)); err != ErrNoCode {
+		t.Errorf("want %v, got %v", ErrNoCode, err)
+	}
+}
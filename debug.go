@@ -0,0 +1,178 @@
+package main
+
+import "fmt"
+
+// Breakpoints stop Run before an instruction, watchpoints after the
+// instruction that touched what they watch. Either way Run leaves the
+// machine Paused and returns a *Stop; running again goes on from there.
+// Step ignores breakpoints but stops at watchpoints.
+
+// A Breakpoint reports whether to stop before the instruction inst at pc.
+type Breakpoint func(m *Arch, pc, inst Word) bool
+
+// BreakAt stops at location pc.
+func BreakAt(pc Word) Breakpoint {
+	return func(m *Arch, at, inst Word) bool { return at == pc }
+}
+
+// BreakOnOpcode stops at instructions with opcode c and field F,
+// or any field when F is -1.
+func BreakOnOpcode(c, F Word) Breakpoint {
+	return func(m *Arch, pc, inst Word) bool {
+		return m.b.c(inst) == c && (F == -1 || m.b.f(inst) == F)
+	}
+}
+
+// BreakWhen stops when cond holds, a test of registers or memory
+// such as func(m *Arch) bool { return m.R[I1].w == 0 }.
+func BreakWhen(cond func(m *Arch) bool) Breakpoint {
+	return func(m *Arch, pc, inst Word) bool { return cond(m) }
+}
+
+// Access is how a watched location is used.
+type Access int
+
+const (
+	OnRead Access = 1 << iota
+	OnWrite
+)
+
+func (a Access) String() string {
+	switch a {
+	case OnRead:
+		return "read"
+	case OnWrite:
+		return "write"
+	case OnRead | OnWrite:
+		return "read or write"
+	}
+	return "none"
+}
+
+// Stop is the error Run and Step return when a breakpoint or
+// watchpoint stops the machine.
+type Stop struct {
+	ID       int    // of the breakpoint or watchpoint
+	PC       Word   // the instruction about to run, or the one that made the access
+	Access   Access // for a watchpoint, 0 for a breakpoint
+	Address  Word   // the location accessed, for a memory watchpoint
+	Register int    // the register accessed, for a register watchpoint, else NoR
+	Old, New Word   // the value before and after the access
+}
+
+func (s *Stop) Error() string {
+	switch {
+	case s.Access == 0:
+		return fmt.Sprintf("breakpoint %d at %d", s.ID, s.PC)
+	case s.Register != NoR:
+		return fmt.Sprintf("watchpoint %d at %d: %v r%s, %d -> %d", s.ID, s.PC, s.Access, regNames[s.Register], s.Old, s.New)
+	}
+	return fmt.Sprintf("watchpoint %d at %d: %v %d, %d -> %d", s.ID, s.PC, s.Access, s.Address, s.Old, s.New)
+}
+
+type breakpoint struct {
+	id int
+	bp Breakpoint
+}
+
+// watchpoint watches locations from through to, or register when it isn't NoR.
+type watchpoint struct {
+	id       int
+	from, to Word
+	on       Access
+	register int
+}
+
+// Break sets bp and returns its ID, for Clear.
+func (m *Arch) Break(bp Breakpoint) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.points++
+	m.breakpoints = append(m.breakpoints, breakpoint{m.points, bp})
+	return m.points
+}
+
+// WatchMemory stops when an instruction accesses locations from through to
+// as given by on, and returns the watchpoint's ID, for Clear. Instructions
+// are seen reading their operands, not fetching themselves, and stores
+// are writes even when they only replace part of a cell.
+func (m *Arch) WatchMemory(from, to Word, on Access) int {
+	return m.watch(watchpoint{from: from, to: to, on: on, register: NoR})
+}
+
+// WatchRegister stops when an instruction accesses register r (A, I1, ..., J)
+// as given by on, and returns the watchpoint's ID, for Clear. Index
+// registers are read by the instructions indexed with them, and writes
+// are seen even when they leave the register as it was.
+func (m *Arch) WatchRegister(r int, on Access) int {
+	return m.watch(watchpoint{on: on, register: r})
+}
+
+func (m *Arch) watch(w watchpoint) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.points++
+	w.id = m.points
+	m.watches = append(m.watches, w)
+	return w.id
+}
+
+// Clear removes the breakpoint or watchpoint id.
+func (m *Arch) Clear(id int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, b := range m.breakpoints {
+		if b.id == id {
+			m.breakpoints = append(m.breakpoints[:i], m.breakpoints[i+1:]...)
+			return
+		}
+	}
+	for i, w := range m.watches {
+		if w.id == id {
+			m.watches = append(m.watches[:i], m.watches[i+1:]...)
+			return
+		}
+	}
+}
+
+// breakpoint returns the Stop of the first breakpoint holding at PC, if any.
+func (m *Arch) breakpoint() *Stop {
+	if len(m.breakpoints) == 0 || !m.inRange(m.PC) {
+		return nil
+	}
+	inst := m.peek(m.PC)
+	for _, b := range m.breakpoints {
+		if b.bp(m, m.PC, inst) {
+			return &Stop{ID: b.id, PC: m.PC, Register: NoR}
+		}
+	}
+	return nil
+}
+
+// watched notes the first access of the step running
+// that a memory watchpoint is watching.
+func (m *Arch) watched(address Word, on Access, old, new Word) {
+	if m.stop != nil {
+		return
+	}
+	for _, w := range m.watches {
+		if w.register == NoR && w.on&on != 0 && w.from <= address && address <= w.to {
+			m.stop = &Stop{ID: w.id, Access: on, Address: address, Register: NoR, Old: old, New: new}
+			return
+		}
+	}
+}
+
+// watchedRegister notes the first access of the step running
+// that a register watchpoint is watching.
+func (m *Arch) watchedRegister(r Word, on Access, old, new Word) {
+	if m.stop != nil {
+		return
+	}
+	for _, w := range m.watches {
+		if w.register == int(r) && w.on&on != 0 {
+			m.stop = &Stop{ID: w.id, Access: on, Register: w.register, Old: old, New: new}
+			return
+		}
+	}
+}
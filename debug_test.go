@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBreakpoints(t *testing.T) {
+	jmp := composeInst(3009, 0, 0, 39)
+	tests := []struct {
+		name  string
+		set   func(m *Arch) int
+		pcs   []Word // of each stop
+		first Stop
+	}{
+		{"at", func(m *Arch) int { return m.Break(BreakAt(3003)) },
+			[]Word{3003, 3003, 3003, 3003}, Stop{PC: 3003, Register: NoR}},
+		{"opcode", func(m *Arch) int { return m.Break(BreakOnOpcode(C_ADDR_TRANSFER+I2, 2)) },
+			[]Word{3005, 3005, 3005}, Stop{PC: 3005, Register: NoR}},
+		{"when", func(m *Arch) int { return m.Break(BreakWhen(func(m *Arch) bool { return m.R[I3].w == 2 })) },
+			[]Word{3008, 3003, 3004, 3007}, Stop{PC: 3008, Register: NoR}},
+		{"read", func(m *Arch) int { return m.WatchMemory(1001, 1005, OnRead) },
+			[]Word{3006, 3003, 3003, 3006, 3003, 3003, 3006},
+			Stop{PC: 3006, Access: OnRead, Address: 1005, Register: NoR, Old: 3, New: 3}},
+		{"write", func(m *Arch) int { return m.WatchMemory(3009, 3009, OnRead|OnWrite) },
+			[]Word{3000}, Stop{PC: 3000, Access: OnWrite, Address: 3009, Register: NoR, Old: jmp, New: composeInst(3012, 0, 0, 39)}},
+		{"register", func(m *Arch) int { return m.WatchRegister(I2, OnWrite) },
+			[]Word{3005, 3005, 3005}, Stop{PC: 3005, Access: OnWrite, Register: I2, Old: 0, New: 5}},
+		{"index", func(m *Arch) int { return m.WatchRegister(I1, OnRead) },
+			[]Word{3001}, Stop{PC: 3001, Access: OnRead, Register: I1, Old: 5, New: 5}},
+		{"cleared", func(m *Arch) int {
+			m.Clear(m.Break(BreakAt(3003)))
+			return 0
+		}, nil, Stop{}},
+	}
+	for _, test := range tests {
+		m := NewMachine()
+		start, err := NewAssembler().Assemble(m, strings.NewReader(programM))
+		if err != nil {
+			t.Fatal(err)
+		}
+		copy(m.Mem[1001:], []Word{5, 1, 4, 1, 3})
+		m.PC = start
+		id := test.set(m)
+		var pcs []Word
+		for len(pcs) <= len(test.pcs) {
+			err := m.Run(context.Background())
+			var s *Stop
+			if !errors.As(err, &s) {
+				if err != nil {
+					t.Fatalf("%s: %v", test.name, err)
+				}
+				break
+			}
+			if m.State != Paused {
+				t.Errorf("%s: want paused, got %v", test.name, m.State)
+			}
+			if want := test.first; len(pcs) == 0 {
+				want.ID = id
+				if *s != want {
+					t.Errorf("%s: want %+v, got %+v", test.name, want, *s)
+				}
+			}
+			pcs = append(pcs, s.PC)
+		}
+		if len(pcs) != len(test.pcs) {
+			t.Errorf("%s: want stops at %v, got %v", test.name, test.pcs, pcs)
+			continue
+		}
+		for i := range pcs {
+			if pcs[i] != test.pcs[i] {
+				t.Errorf("%s: want stops at %v, got %v", test.name, test.pcs, pcs)
+				break
+			}
+		}
+		if m.State != Halted || m.R[A].w != 5 {
+			t.Errorf("%s: want halted with rA 5, got %v with %v", test.name, m.State, m.R[A].w)
+		}
+	}
+}
+
+// TestWatchRegister tests register watchpoints see accesses, not changes.
+func TestWatchRegister(t *testing.T) {
+	tests := []struct {
+		inst Word
+		r    int
+		on   Access
+	}{
+		{composeInst(0, 0, 2, C_ADDR_TRANSFER), A, OnWrite},     // ENTA 0
+		{composeInst(1000, 0, 5, C_ST+X), X, OnRead},            // STX 1000
+		{composeInst(1000, 4, 5, C_LD), I4, OnRead},             // LDA 1000,4
+		{composeInst(1000, 0, 5, C_CMP+I6), I6, OnRead},         // CMP6 1000
+		{composeInst(1000, 0, 5, C_ADD), A, OnRead | OnWrite},   // ADD 1000
+		{composeInst(0, 0, 0, C_ADDR_TRANSFER+I5), I5, OnWrite}, // INC5 0
+		{composeInst(2, 0, 0, C_JMP), J, OnWrite},               // JMP 2
+	}
+	for _, test := range tests {
+		m := NewMachine()
+		m.WatchRegister(test.r, test.on)
+		m.Mem[0], m.R[J].w = test.inst, 1
+		err := m.Step()
+		var s *Stop
+		if !errors.As(err, &s) || s.Register != test.r || s.Access&test.on == 0 || s.Old != s.New {
+			t.Errorf("%v: want a stop on r%s, unchanged, got %v", m.b.disassemble(test.inst), regNames[test.r], err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+// decoded is an instruction taken apart once, so a loop run many times
+// doesn't take its instructions apart on every pass.
+type decoded struct {
+	inst Word // the word decoded
+	A, I Word // address and index register
+	op   *Op
+	time Word  // see duration
+	err  error // from check, op is nil when set
+	ok   bool  // inst is decoded
+}
+
+// decode takes inst apart and checks it against the machine.
+func (m *Arch) decode(inst Word) decoded {
+	d := decoded{inst: inst, A: m.b.a(inst), I: m.b.i(inst), ok: true}
+	F := m.b.f(inst)
+	if d.op, d.err = m.check(m.b.c(inst), F); d.err == nil {
+		d.time = d.op.duration(F)
+	}
+	return d
+}
+
+// fetch returns the instruction at pc decoded. Each location keeps the
+// word it last decoded and decodes again once the cell holds another,
+// however it was written, so programs can still modify themselves.
+// Control state locations are decoded every time.
+func (m *Arch) fetch(pc Word) *decoded {
+	inst := m.peek(pc)
+	if pc < 0 || Word(len(m.decoded)) <= pc {
+		d := m.decode(inst)
+		return &d
+	}
+	d := &m.decoded[pc]
+	if !d.ok || d.inst != inst {
+		*d = m.decode(inst)
+	}
+	return d
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// programS is straight insertion (TAOCP 5.2.1, Program S) of N words,
+// local symbols spelled out.
+const programS = `N        EQU  200
+INPUT    EQU  1000
+         ORIG 3000
+START    ENT1 2-N
+TWO      LDA  INPUT+N,1
+         ENT2 N-1,1
+THREE    CMPA INPUT,2
+         JGE  FIVE
+         LDX  INPUT,2
+         STX  INPUT+1,2
+         DEC2 1
+         J2P  THREE
+FIVE     STA  INPUT+1,2
+         INC1 1
+         J1NP TWO
+         HLT
+         END  START
+`
+
+// sortMachine returns a machine with Program S and its start,
+// ready for fill to give it reversed input.
+func sortMachine(tb testing.TB) (*Arch, Word) {
+	m := NewMachine()
+	start, err := NewAssembler().Assemble(m, strings.NewReader(programS))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return m, start
+}
+
+func fill(m *Arch, start Word) {
+	for i := Word(1); i <= 200; i++ {
+		m.Mem[1000+i] = 201 - i
+	}
+	m.PC = start
+}
+
+func TestFetchSelfModifying(t *testing.T) {
+	m := NewMachine()
+	m.Mem[0] = composeInst(1, 0, 2, 49)  // ENT1 1
+	m.Mem[1] = composeInst(10, 0, 5, 8)  // LDA 10
+	m.Mem[2] = composeInst(0, 0, 5, 24)  // STA 0
+	m.Mem[3] = composeInst(0, 0, 0, 39)  // JMP 0
+	m.Mem[10] = composeInst(7, 0, 2, 49) // ENT1 7
+	for i := 0; i < 5; i++ {
+		if err := m.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if v := m.R[I1].w; v != 7 {
+		t.Errorf("stored over: want rI1 7, got %v", v)
+	}
+	m.Mem[0], m.PC = composeInst(3, 0, 2, 49), 0 // ENT1 3
+	if err := m.Step(); err != nil {
+		t.Fatal(err)
+	}
+	if v := m.R[I1].w; v != 3 {
+		t.Errorf("set in Mem: want rI1 3, got %v", v)
+	}
+}
+
+func BenchmarkRun(b *testing.B) {
+	m, start := sortMachine(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fill(m, start)
+		if err := m.Run(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(m.Executed)/float64(b.N), "inst/op")
+	sorted := true
+	for i := Word(1); i <= 200; i++ {
+		sorted = sorted && m.Mem[1000+i] == i
+	}
+	if !sorted {
+		b.Error("input not sorted")
+	}
+}
+
+// BenchmarkExec runs Program S decoding each instruction as it goes.
+func BenchmarkExec(b *testing.B) {
+	m, start := sortMachine(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fill(m, start)
+		for m.State = Running; m.State == Running; {
+			pc := m.PC
+			m.PC++
+			if err := m.Exec(m.Mem[pc]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import "fmt"
+
+// disassemble returns inst as MIXAL, such as "LDA 1000,1(1:3)".
+func (b ByteSize) disassemble(inst Word) string {
+	F := b.f(inst)
+	op, _ := lookupOp(b.c(inst), F)
+	if op == nil {
+		return b.decode(inst)
+	}
+	s := fmt.Sprintf("%s %d", op.Name, b.a(inst))
+	if inst < 0 && b.a(inst) == 0 {
+		s = op.Name + " -0"
+	}
+	if i := b.i(inst); i != 0 {
+		s += fmt.Sprintf(",%d", i)
+	}
+	switch {
+	case op.FKind == FSelect || F == op.F:
+	case op.FKind == FField:
+		s += fmt.Sprintf("(%d:%d)", F/8, F%8)
+	default:
+		s += fmt.Sprintf("(%d)", F)
+	}
+	return s
+}
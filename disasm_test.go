@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestDisassemble(t *testing.T) {
+	tests := []struct {
+		Inst Word
+		Want string
+	}{
+		{composeInst(1000, 0, 5, C_LD), "LDA 1000"},
+		{-composeInst(5, 2, 1*8+3, C_LD+I1), "LD1 -5,2(1:3)"},
+		{composeInst(1000, 0, 2, 32), "STJ 1000"},
+		{composeInst(1000, 0, 5, 32), "STJ 1000(0:5)"},
+		{composeInst(1000, 0, 6, C_ADD), "FADD 1000"},
+		{composeInst(3, 0, 3, C_SHIFT), "SRAX 3"},
+		{composeInst(1000, 0, 4, C_MOVE), "MOVE 1000(4)"},
+		{composeInst(1000, 0, 18, C_IO+3), "OUT 1000(18)"},
+		{composeInst(1000, 0, 3, C_JREG+X), "JXNN 1000"},
+		{composeInst(1, 0, 1, C_ADDR_TRANSFER+I3), "DEC3 1"},
+		{-composeInst(0, 0, 2, C_ADDR_TRANSFER), "ENTA -0"},
+		{-composeInst(0, 1, 2, C_ADDR_TRANSFER), "ENTA -0,1"},
+		{composeInst(0, 0, 2, C_SPECIAL), "HLT 0"},
+		{composeInst(0, 0, 8, C_SPECIAL), "C=5 A=0 I=0 F=8"},
+	}
+	for _, test := range tests {
+		if got := Binary.disassemble(test.Inst); got != test.Want {
+			t.Errorf("want %q, got %q", test.Want, got)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// FaultKind is the reason an instruction could not execute.
+type FaultKind int
+
+const (
+	InvalidOpcode  FaultKind = iota // no such instruction on this machine
+	AddressRange                    // a location outside of memory
+	InvalidField                    // F isn't valid for the opcode
+	IndexRange                      // no such index register, or it overflowed
+	DivideOverflow                  // division by 0 or a quotient over five bytes
+	DeviceError                     // an I/O device failed
+)
+
+func (k FaultKind) String() string {
+	switch k {
+	case InvalidOpcode:
+		return "invalid opcode"
+	case AddressRange:
+		return "address out of range"
+	case InvalidField:
+		return "invalid field"
+	case IndexRange:
+		return "index register out of range"
+	case DivideOverflow:
+		return "divide overflow"
+	case DeviceError:
+		return "device error"
+	}
+	return "unknown fault"
+}
+
+var faultKinds = map[error]FaultKind{
+	ErrOpcode:        InvalidOpcode,
+	ErrNoFloat:       InvalidOpcode,
+	ErrNoBinary:      InvalidOpcode,
+	ErrNoInterrupts:  InvalidOpcode,
+	ErrAddress:       AddressRange,
+	ErrPCRange:       AddressRange,
+	ErrShift:         AddressRange,
+	ErrField:         InvalidField,
+	ErrNoIO:          InvalidField,
+	ErrIndex:         IndexRange,
+	ErrIndexOverflow: IndexRange,
+	ErrDivide:        DivideOverflow,
+	ErrDevice:        DeviceError,
+}
+
+// Fault is the error Step and Exec return when an instruction can't execute.
+// Err is the exec sentinel giving the details, so errors.Is works on a Fault.
+type Fault struct {
+	Kind FaultKind
+	PC   Word   // location of the instruction
+	Inst Word   // the instruction, 0 if it couldn't be fetched
+	View string // Inst decoded
+	Err  error
+}
+
+func (f *Fault) Error() string {
+	return fmt.Sprintf("%v at %d (%s): %v", f.Kind, f.PC, f.View, f.Err)
+}
+
+func (f *Fault) Unwrap() error {
+	return f.Err
+}
+
+func (m *Arch) fault(err error, pc, inst Word) *Fault {
+	f := &Fault{PC: pc, Inst: inst, View: m.b.decode(inst), Err: err}
+	for sentinel, kind := range faultKinds {
+		if errors.Is(err, sentinel) {
+			f.Kind = kind
+		}
+	}
+	return f
+}
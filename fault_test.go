@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFault(t *testing.T) {
+	tests := []struct {
+		Inst Word
+		Kind FaultKind
+		Err  error
+	}{
+		{composeInst(1000, 0, 6, C_ADD), InvalidOpcode, ErrNoFloat}, // FADD 1000
+		{composeInst(16, 0, 0, C_IO+2), InvalidField, ErrNoIO},      // IN 16
+		{composeInst(4000, 0, 5, C_LD), AddressRange, ErrAddress},   // LDA 4000
+		{-composeInst(1, 0, 0, C_SHIFT), AddressRange, ErrShift},    // SLA -1
+		{composeInst(1000, 0, 3*8+2, C_LD), InvalidField, ErrField}, // LDA 1000(3:2)
+		{composeInst(1000, 0, 6, C_LD), InvalidField, ErrField},     // LDA 1000(0:6)
+		{composeInst(0, 0, 3, C_SPECIAL), InvalidField, ErrField},   // C=5 F=3
+		{composeInst(1000, 0, 10, C_JMP), InvalidField, ErrField},   // C=39 F=10
+		{composeInst(1000, 7, 5, C_LD), IndexRange, ErrIndex},       // LDA 1000,7
+		{composeInst(1000, 0, 5, C_DIV), DivideOverflow, ErrDivide}, // DIV 1000
+	}
+	for _, test := range tests {
+		m := NewMachine()
+		m.PC = 10
+		m.Write(10, test.Inst)
+		m.R[A].w, m.R[I1].w = 7, 100
+		err := m.Step()
+		var f *Fault
+		if !errors.As(err, &f) {
+			t.Fatalf("%v: want a *Fault, got %v", test.Inst.view(), err)
+		}
+		if f.Kind != test.Kind || !errors.Is(err, test.Err) || f.PC != 10 || f.Inst != test.Inst {
+			t.Errorf("want %v (%v) at 10, got %v", test.Kind, test.Err, err)
+		}
+		if m.State != Faulted || m.PC != 10 || m.R[A].w != 7 || m.Executed != 0 {
+			t.Errorf("%v: machine changed by fault, state %v, PC %v, rA %v", err, m.State, m.PC, m.R[A].w)
+		}
+	}
+
+	m := NewMachine(WithByteSize(Decimal))
+	var f *Fault
+	err := m.Exec(Decimal.inst(1000, 0, 5, 64))
+	if !errors.As(err, &f) || f.Kind != InvalidOpcode || f.View != "C=64 A=1000 I=0 F=5" {
+		t.Errorf("want invalid opcode 64, got %v", err)
+	}
+	m = NewMachine(WithStrict())
+	m.R[I1].w = 100
+	err = m.Exec(composeInst(4000, 0, 0, C_ADDR_TRANSFER+I1)) // INC1 4000
+	if !errors.As(err, &f) || f.Kind != IndexRange || !errors.Is(err, ErrIndexOverflow) {
+		t.Errorf("strict: want %v, got %v", IndexRange, err)
+	}
+
+	// NOP ignores its address, index and field, so any word with C=0 runs.
+	for _, inst := range []Word{
+		composeInst(0, 5, 0, 0),     // NOP 0,5
+		composeInst(0, 0, 5, 0),     // NOP 0(5)
+		composeInst(4000, 7, 63, 0), // NOP 4000,7(63)
+		-composeWord(0, 1, 2, 3, 0), // data
+	} {
+		m := NewMachine()
+		m.Mem[0] = inst
+		if err := m.Step(); err != nil || m.PC != 1 {
+			t.Errorf("%v: want NOP, got %v", inst.view(), err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// Floating point attachment (TAOCP 4.2.1). A floating point word holds
+// a sign, a one byte exponent in excess q = b/2 and a four byte fraction,
+// ±f × b^(e-q) with 0 <= f < 1, normalized when the first fraction byte
+// is nonzero. Fractions are worked on as integers scaled by b^8, four
+// fraction bytes followed by four guard bytes kept until rounding.
+const fpBytes = 4
+
+var ErrNoFloat = errors.New("exec: floating point attachment not installed")
+
+// unpack splits w into its sign, exponent and fraction,
+// the fraction scaled by b^8.
+func (m *Arch) unpack(w Word) (sign, e, f Word) {
+	frac := m.b.pow(fpBytes)
+	return w.sign(), w.data() / frac, w.data() % frac * frac
+}
+
+// normalize rounds and packs ±f × b^(e-q), f scaled by b^8
+// (Algorithm 4.2.1N). An exponent outside of a byte sets the overflow
+// toggle and is kept mod b.
+func (m *Arch) normalize(sign, e, f Word) Word {
+	if f == 0 {
+		return signed(sign, 0)
+	}
+	b, frac := Word(m.b), m.b.pow(fpBytes)
+	for frac*frac <= f {
+		f /= b
+		e++
+	}
+	for f < frac*frac/b {
+		f *= b
+		e--
+	}
+	f = (f + frac/2) / frac
+	if f == frac {
+		f /= b
+		e++
+	}
+	if e < 0 || b <= e {
+		m.OverflowToggle = true
+		e = (e%b + b) % b
+	}
+	return signed(sign, e*frac+f)
+}
+
+// Float executes a floating point instruction. Arithmetic and FCMP
+// take all of the cell at M as V, FLOT and FIX only work on rA.
+func (m *Arch) Float(inst, M Word) {
+	c, F := m.b.c(inst), m.b.f(inst)
+	q, frac := Word(m.b/2), m.b.pow(fpBytes)
+	switch {
+	case c == C_SPECIAL && F == 6: // FLOT
+		m.setReg(A, m.normalize(m.reg(A).sign(), q+WORDSIZE, m.reg(A).data()*m.b.pow(2*fpBytes-WORDSIZE)))
+	case c == C_SPECIAL && F == 7: // FIX
+		m.setReg(A, m.fix(m.reg(A)))
+	case c == C_CMP: // FCMP
+		m.SetComparisons(m.fcmp(m.reg(A), m.Read(M)))
+	case c == C_ADD:
+		m.setReg(A, m.fadd(m.reg(A), m.Read(M)))
+	case c == C_SUB:
+		m.setReg(A, m.fadd(m.reg(A), m.Read(M).neg()))
+	case c == C_MUL:
+		su, eu, fu := m.unpack(m.reg(A))
+		sv, ev, fv := m.unpack(m.Read(M))
+		m.setReg(A, m.normalize(su*sv, eu+ev-q, fu/frac*(fv/frac)))
+	case c == C_DIV:
+		su, eu, fu := m.unpack(m.reg(A))
+		sv, ev, fv := m.unpack(m.Read(M))
+		// (fu / b) / fv scaled by b^8 is fu × b^7 / fv, unscaled
+		hi, lo := bits.Mul64(uint64(fu/frac), uint64(m.b.pow(2*fpBytes-1)))
+		if fv == 0 || uint64(fv/frac) <= hi {
+			m.OverflowToggle = true
+			return
+		}
+		f, _ := bits.Div64(hi, lo, uint64(fv/frac))
+		m.setReg(A, m.normalize(su*sv, eu-ev+q+1, Word(f)))
+	}
+}
+
+// fadd returns u + v (Algorithm 4.2.1A).
+func (m *Arch) fadd(u, v Word) Word {
+	su, eu, fu := m.unpack(u)
+	sv, ev, fv := m.unpack(v)
+	if eu < ev {
+		su, eu, fu, sv, ev, fv = sv, ev, fv, su, eu, fu
+	}
+	if d := eu - ev; d <= fpBytes+1 {
+		fv /= m.b.pow(d)
+	} else {
+		fv = 0
+	}
+	sign, sum := Word(1), su*fu+sv*fv
+	if sum < 0 {
+		sign, sum = -1, -sum
+	}
+	return m.normalize(sign, eu, sum)
+}
+
+// fix returns u rounded to the nearest integer, setting the
+// overflow toggle when it needs more than five bytes.
+func (m *Arch) fix(u Word) Word {
+	su, eu, fu := m.unpack(u)
+	fu /= m.b.pow(fpBytes)
+	var v Word
+	if n := eu - Word(m.b/2) - fpBytes; WORDSIZE <= n {
+		if fu != 0 {
+			m.OverflowToggle = true
+		}
+	} else if 0 <= n {
+		if m.b.pow(WORDSIZE-n) <= fu {
+			m.OverflowToggle = true
+		}
+		v = fu % m.b.pow(WORDSIZE-n) * m.b.pow(n)
+	} else if -n <= fpBytes {
+		d := m.b.pow(-n)
+		v = (fu + d/2) / d
+	}
+	return signed(su, v)
+}
+
+// fcmp compares u with v as floating point numbers. They are equal when
+// their difference is within ε × b^(max(eu, ev)-q), ε being the floating
+// point number in location 0 (TAOCP 4.2.2).
+func (m *Arch) fcmp(u, v Word) (lt, eq, gt bool) {
+	_, eu, _ := m.unpack(u)
+	_, ev, _ := m.unpack(v)
+	if eu < ev {
+		eu = ev
+	}
+	diff := m.toFloat64(u) - m.toFloat64(v)
+	tolerance := m.toFloat64(m.Read(0)) * m.b.pow64(eu-Word(m.b/2))
+	return diff < -tolerance, -tolerance <= diff && diff <= tolerance, tolerance < diff
+}
+
+func (m *Arch) toFloat64(w Word) float64 {
+	s, e, f := m.unpack(w)
+	frac := float64(m.b.pow(fpBytes))
+	return float64(s) * float64(f) / frac / frac * m.b.pow64(e-Word(m.b/2))
+}
+
+// pow64 returns b^n for any n.
+func (b ByteSize) pow64(n Word) float64 {
+	p := 1.0
+	for ; 0 < n; n-- {
+		p *= float64(b)
+	}
+	for ; n < 0; n++ {
+		p /= float64(b)
+	}
+	return p
+}
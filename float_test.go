@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFloat(t *testing.T) {
+	one, half := composeWord(33, 1, 0, 0, 0), composeWord(32, 32, 0, 0, 0)
+	big := composeWord(63, 1, 0, 0, 0)
+	tests := []struct {
+		RA, Cell   Word
+		Inst, Want Word
+		Overflowed bool
+	}{
+		{1, 0, composeInst(0, 0, 6, C_SPECIAL), one, false},                                                                               // FLOT
+		{-composeWord(0, 0, 0, 1, 0), 0, composeInst(0, 0, 6, C_SPECIAL), -composeWord(34, 1, 0, 0, 0), false},                            // FLOT
+		{composeWord(33, 1, 32, 0, 0), 0, composeInst(0, 0, 7, C_SPECIAL), 2, false},                                                      // FIX 1.5
+		{-composeWord(34, 1, 0, 0, 0), 0, composeInst(0, 0, 7, C_SPECIAL), -64, false},                                                    // FIX
+		{composeWord(31, 63, 0, 0, 0), 0, composeInst(0, 0, 7, C_SPECIAL), 0, false},                                                      // FIX
+		{big, 0, composeInst(0, 0, 7, C_SPECIAL), 0, true},                                                                                // FIX
+		{one, one, composeInst(1000, 0, 6, C_ADD), composeWord(33, 2, 0, 0, 0), false},                                                    // FADD
+		{one, half, composeInst(1000, 0, 6, C_ADD), composeWord(33, 1, 32, 0, 0), false},                                                  // FADD
+		{one, half, composeInst(1000, 0, 6, C_SUB), half, false},                                                                          // FSUB
+		{one, one, composeInst(1000, 0, 6, C_SUB), 0, false},                                                                              // FSUB
+		{one, composeWord(29, 32, 0, 0, 0), composeInst(1000, 0, 6, C_ADD), composeWord(33, 1, 0, 0, 1), false},                           // FADD rounds up
+		{composeWord(33, 1, 32, 0, 0), composeWord(33, 1, 32, 0, 0), composeInst(1000, 0, 6, C_MUL), composeWord(33, 2, 16, 0, 0), false}, // FMUL
+		{-one, composeWord(33, 3, 0, 0, 0), composeInst(1000, 0, 6, C_DIV), -composeWord(32, 21, 21, 21, 21), false},                      // FDIV
+		{big, big, composeInst(1000, 0, 6, C_MUL), composeWord(29, 1, 0, 0, 0), true},                                                     // FMUL exponent overflow
+		{one, 0, composeInst(1000, 0, 6, C_DIV), one, true},                                                                               // FDIV by 0
+	}
+	for _, test := range tests {
+		m := NewMachine(WithFloatingPoint())
+		m.R[A].w = test.RA
+		m.Write(1000, test.Cell)
+		if err := m.Exec(test.Inst); err != nil {
+			t.Fatal(err)
+		}
+		if m.R[A].w != test.Want || m.OverflowToggle != test.Overflowed {
+			t.Errorf("\n%s\n%s\nOverflow want %v, got %v", test.Inst.instView(), wordDiff(test.Want, m.R[A].w), test.Overflowed, m.OverflowToggle)
+		}
+	}
+
+	if err := NewMachine().Exec(composeInst(1000, 0, 6, C_ADD)); !errors.Is(err, ErrNoFloat) {
+		t.Errorf("want %v, got %v", ErrNoFloat, err)
+	}
+}
+
+func TestFCMP(t *testing.T) {
+	one := composeWord(33, 1, 0, 0, 0)
+	tests := []struct {
+		Epsilon, Cell Word
+		Want          [3]bool
+	}{
+		{0, one, [3]bool{1: true}},
+		{0, composeWord(33, 1, 0, 0, 1), [3]bool{0: true}},
+		{0, composeWord(33, 0, 63, 63, 63), [3]bool{2: true}},
+		{composeWord(29, 1, 0, 0, 0), composeWord(33, 1, 0, 0, 1), [3]bool{1: true}}, // ε = b^-4
+		{composeWord(29, 1, 0, 0, 0), composeWord(33, 1, 0, 1, 0), [3]bool{0: true}},
+	}
+	for _, test := range tests {
+		m := NewMachine(WithFloatingPoint())
+		m.R[A].w = one
+		m.Write(0, test.Epsilon)
+		m.Write(1000, test.Cell)
+		m.Exec(composeInst(1000, 0, 6, C_CMP)) // FCMP 1000
+		if lt, eq, gt := m.Comparisons(); [3]bool{lt, eq, gt} != test.Want {
+			t.Errorf("%s vs %s with ε %s: want %v, got %v", one.view(), test.Cell.view(), test.Epsilon.view(), test.Want, [3]bool{lt, eq, gt})
+		}
+	}
+}
+
+func TestFloatDecimal(t *testing.T) {
+	m := NewMachine(WithFloatingPoint(), WithByteSize(Decimal))
+	m.R[A].w = 3
+	m.Write(1000, Decimal.word(51, 1, 0, 0, 0)) // 1.0
+	steps := []struct {
+		Inst, Want Word
+	}{
+		{Decimal.inst(0, 0, 6, C_SPECIAL), Decimal.word(51, 3, 0, 0, 0)},    // FLOT
+		{Decimal.inst(1000, 0, 6, C_DIV), Decimal.word(51, 3, 0, 0, 0)},     // FDIV 1000
+		{Decimal.inst(1000, 0, 6, C_ADD), Decimal.word(51, 4, 0, 0, 0)},     // FADD 1000
+		{Decimal.inst(1000, 0, 6, C_SUB), Decimal.word(51, 3, 0, 0, 0)},     // FSUB 1000
+		{Decimal.inst(0, 0, 7, C_SPECIAL), 3},                               // FIX
+		{Decimal.inst(0, 0, 6, C_SPECIAL), Decimal.word(51, 3, 0, 0, 0)},    // FLOT
+		{Decimal.inst(1001, 0, 6, C_MUL), Decimal.word(49, 99, 99, 99, 99)}, // FMUL 1001
+		{Decimal.inst(1002, 0, 6, C_DIV), Decimal.word(49, 33, 33, 33, 33)}, // FDIV 1002
+	}
+	m.Write(1001, Decimal.word(49, 33, 33, 33, 33)) // 0.0033333333
+	m.Write(1002, Decimal.word(51, 3, 0, 0, 0))     // 3.0
+	for _, step := range steps {
+		if err := m.Exec(step.Inst); err != nil {
+			t.Fatal(err)
+		}
+		if m.R[A].w != step.Want {
+			t.Errorf("\n%s\nWant:%s\nGot:%s", Decimal.view(step.Inst), Decimal.view(step.Want), Decimal.view(m.R[A].w))
+		}
+	}
+}
@@ -5,9 +5,8 @@ import (
 )
 
 // A returns the address of inst (sign, A, A)
-func (inst Word) a() Word {
-	data := inst.data()
-	data >>= 18
+func (b ByteSize) a(inst Word) Word {
+	data := inst.data() / b.pow(3)
 	if inst < 0 {
 		data = -data
 	}
@@ -15,27 +14,48 @@ func (inst Word) a() Word {
 }
 
 // I returns the index register of inst (I).
-func (inst Word) i() Word {
-	return inst.data() >> 12 & 63
+func (b ByteSize) i(inst Word) Word {
+	return b.field(inst.data(), 3, 3)
 }
 
 // F returns the field specification of inst (F).
-func (inst Word) f() Word {
-	return inst.data() >> 6 & 63
+func (b ByteSize) f(inst Word) Word {
+	return b.field(inst.data(), 4, 4)
 }
 
-func (inst Word) fLR() (L, R Word) {
-	return inst.f() / 8, inst.f() % 8
+func (b ByteSize) fLR(inst Word) (L, R Word) {
+	F := b.f(inst)
+	return F / 8, F % 8
 }
 
 // C returns the opcode of inst (C).
-func (inst Word) c() Word {
-	return inst.data() & 63
+func (b ByteSize) c(inst Word) Word {
+	return b.field(inst.data(), 5, 5)
 }
 
 // args assumed positive
+func (b ByteSize) inst(a, i, f, c Word) Word {
+	return b.word(a/Word(b), a, i, f, c)
+}
+
+// decode shows the fields of inst on one line.
+func (b ByteSize) decode(inst Word) string {
+	return fmt.Sprintf("C=%d A=%d I=%d F=%d", b.c(inst), b.a(inst), b.i(inst), b.f(inst))
+}
+
+// The instruction helpers below work with binary bytes, like composeWord.
+
+func (inst Word) a() Word { return Binary.a(inst) }
+func (inst Word) i() Word { return Binary.i(inst) }
+func (inst Word) f() Word { return Binary.f(inst) }
+func (inst Word) c() Word { return Binary.c(inst) }
+
+func (inst Word) fLR() (L, R Word) {
+	return Binary.fLR(inst)
+}
+
 func composeInst(a, i, f, c Word) Word {
-	return Word(a&4095<<18 | i&63<<12 | f&63<<6 | c&63)
+	return Binary.inst(a, i, f, c)
 }
 
 func (inst Word) instView() string {
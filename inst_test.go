@@ -1,7 +1,8 @@
 package main
 
 import (
-	//	"errors"
+	"context"
+	"errors"
 	"testing"
 )
 
@@ -65,7 +66,7 @@ func TestLD(t *testing.T) {
 			Want: -composeWord(0, 0, 1, 2, 3),
 		},
 		{
-			Inst: composeInst(2000, 4, 37, C_LD), // LDA 2000,4(4:5), but index has no effect here
+			Inst: composeInst(2000, 4, 37, C_LD), // LDA 2000,4(4:5), rI4 is 0 so M is 2000
 			RegI: A,
 			Want: composeWord(0, 0, 0, 4, 5),
 		},
@@ -80,6 +81,11 @@ func TestLD(t *testing.T) {
 			RegI: A,
 			Want: composeWord(1, 2, 3, 4, 5),
 		},
+		{
+			Inst: composeInst(2000, 0, 1*8+5, C_LDN), // LDAN 2000(1:5)
+			RegI: A,
+			Want: -composeWord(1, 2, 3, 4, 5),
+		},
 	}
 	for _, test := range tests {
 		inst := test.Inst
@@ -93,6 +99,39 @@ func TestLD(t *testing.T) {
 	}
 }
 
+// TestAddress tests effective address computation with index registers.
+func TestAddress(t *testing.T) {
+	m := NewMachine()
+	m.R[I3].w = 10
+	m.R[I5].w = -1000
+	tests := []struct {
+		Inst, Want Word
+		Err        error
+	}{
+		{Inst: composeInst(1000, 0, 5, C_LD), Want: 1000},                  // LDA 1000
+		{Inst: composeInst(1000, 3, 5, C_LD), Want: 1010},                  // LDA 1000,3
+		{Inst: -composeInst(5, 3, 5, C_LD), Want: 5},                       // LDA -5,3
+		{Inst: composeInst(1000, 5, 5, C_LD), Want: 0},                     // LDA 1000,5
+		{Inst: composeInst(1000, 7, 5, C_LD), Err: ErrIndex},               // LDA 1000,7
+		{Inst: composeInst(999, 5, 5, C_LD), Want: -1, Err: ErrAddress},    // LDA 999,5
+		{Inst: composeInst(4000, 0, 5, C_LD), Want: 4000, Err: ErrAddress}, // LDA 4000
+	}
+	for _, test := range tests {
+		M, err := m.Address(test.Inst)
+		if err == nil {
+			err = m.Exec(test.Inst)
+		}
+		if M != test.Want || !errors.Is(err, test.Err) {
+			t.Errorf("\n%s\n\nWant: %v, %v\nGot: %v, %v\n", test.Inst.instView(), test.Want, test.Err, M, err)
+		}
+	}
+	m.Write(1010, 77)
+	m.Exec(composeInst(1000, 3, 5, C_LD)) // LDA 1000,3
+	if m.R[A].w != 77 {
+		t.Error(wordDiff(77, m.R[A].w))
+	}
+}
+
 type RegState struct {
 	I    int
 	Data Word
@@ -141,44 +180,270 @@ func TestST(t *testing.T) {
 	}
 }
 
-/*
-func TestArithmetic(t *testing.T) {
+// TestShift runs the shift sequence given as an example in TAOCP 1.3.1.
+func TestShift(t *testing.T) {
+	m := NewMachine()
+	m.R[A].w = composeWord(1, 2, 3, 4, 5)
+	m.R[X].w = -composeWord(6, 7, 8, 9, 10)
 	tests := []struct {
-		Line         string
-		RAData, Want MIXBytes
+		Inst, WantA, WantX Word
 	}{
-		{
-			Line:   "ADD 1000",
-			RAData: MIXBytes{},
-			Want:   MIXBytes{},
-		},
+		{composeInst(1, 0, 3, C_SHIFT), composeWord(0, 1, 2, 3, 4), -composeWord(5, 6, 7, 8, 9)},   // SRAX 1
+		{composeInst(2, 0, 0, C_SHIFT), composeWord(2, 3, 4, 0, 0), -composeWord(5, 6, 7, 8, 9)},   // SLA 2
+		{composeInst(4, 0, 5, C_SHIFT), composeWord(6, 7, 8, 9, 2), -composeWord(3, 4, 0, 0, 5)},   // SRC 4
+		{composeInst(2, 0, 1, C_SHIFT), composeWord(0, 0, 6, 7, 8), -composeWord(3, 4, 0, 0, 5)},   // SRA 2
+		{composeInst(501, 0, 4, C_SHIFT), composeWord(0, 6, 7, 8, 3), -composeWord(4, 0, 0, 5, 0)}, // SLC 501
+		{composeInst(12, 0, 2, C_SHIFT), composeWord(0, 0, 0, 0, 0), negZero},                      // SLAX 12
 	}
 	for _, test := range tests {
-		_, err := ParseInst(test.Line)
-		if err != nil {
-			t.Fatalf("Error parsing %s: %v", test.Line, err)
+		if err := m.Exec(test.Inst); err != nil {
+			t.Fatal(err)
+		}
+		if m.R[A].w != test.WantA || m.R[X].w != test.WantX {
+			t.Errorf("\n%s\nrA:%s\nrX:%s\n", test.Inst.instView(), wordDiff(test.WantA, m.R[A].w), wordDiff(test.WantX, m.R[X].w))
 		}
-		t.Error("N/A")
+	}
+	if err := m.Exec(-composeInst(1, 0, 0, C_SHIFT)); !errors.Is(err, ErrShift) { // SLA -1
+		t.Errorf("want %v, got %v", ErrShift, err)
 	}
 }
 
-func TestAddressTransfer(t *testing.T) {
-	t.Error("N/A")
+// TestMove tests MOVE, including overlapping source and destination.
+func TestMove(t *testing.T) {
+	tests := []struct {
+		Inst, RI1 Word
+		Want      []Word // Mem[1000:1006] after the move
+	}{
+		{composeInst(1000, 0, 3, C_MOVE), 1003, []Word{1, 2, 3, 1, 2, 3}}, // MOVE 1000(3)
+		{composeInst(1000, 0, 4, C_MOVE), 1001, []Word{1, 1, 1, 1, 1, 6}}, // MOVE 1000(4), overlaps forward
+		{composeInst(1001, 0, 4, C_MOVE), 1000, []Word{2, 3, 4, 5, 5, 6}}, // MOVE 1001(4), overlaps backward
+		{composeInst(1000, 0, 0, C_MOVE), 1003, []Word{1, 2, 3, 4, 5, 6}}, // MOVE 1000(0)
+	}
+	for _, test := range tests {
+		m := NewMachine()
+		copy(m.Mem[1000:], []Word{1, 2, 3, 4, 5, 6})
+		m.R[I1].w = test.RI1
+		if err := m.Exec(test.Inst); err != nil {
+			t.Fatal(err)
+		}
+		F := test.Inst.f()
+		for i, want := range test.Want {
+			if got := m.Read(1000 + Word(i)); got != want {
+				t.Errorf("\n%s\nMem[%d]:%s", test.Inst.instView(), 1000+i, wordDiff(want, got))
+			}
+		}
+		if m.R[I1].w != test.RI1+F || m.Time != 1+2*F {
+			t.Errorf("want rI1 %v and time %v, got %v and %v", test.RI1+F, 1+2*F, m.R[I1].w, m.Time)
+		}
+	}
+	m := NewMachine()
+	m.R[I1].w = 3999
+	if err := m.Exec(composeInst(0, 0, 2, C_MOVE)); !errors.Is(err, ErrAddress) {
+		t.Errorf("want %v, got %v", ErrAddress, err)
+	}
 }
 
-func TestComparison(t *testing.T) {
-	t.Error("N/A")
+// TestConversion runs the NUM and CHAR example given in TAOCP 1.3.1.
+func TestConversion(t *testing.T) {
+	m := NewMachine()
+	m.R[A].w = -composeWord(0, 0, 31, 32, 39)
+	m.R[X].w = composeWord(37, 57, 47, 30, 30)
+	m.Exec(composeInst(0, 0, 0, C_SPECIAL)) // NUM 0
+	if want := Word(-12977700); m.R[A].w != want {
+		t.Error(wordDiff(want, m.R[A].w))
+	}
+	m.R[A].w = -12977699                    // INCA 1
+	m.Exec(composeInst(0, 0, 1, C_SPECIAL)) // CHAR 0
+	if want := -composeWord(30, 30, 31, 32, 39); m.R[A].w != want {
+		t.Error(wordDiff(want, m.R[A].w))
+	}
+	if want := composeWord(37, 37, 36, 39, 39); m.R[X].w != want {
+		t.Error(wordDiff(want, m.R[X].w))
+	}
 }
 
+// TestJump tests jump conditions and rJ, with PC already advanced to 101.
 func TestJump(t *testing.T) {
-	t.Error("N/A")
+	tests := []struct {
+		Inst         Word
+		Overflow     bool
+		CI           [3]bool // less, equal, greater
+		RegVal       Word
+		WantPC, Want Word // Want is rJ
+	}{
+		{Inst: composeInst(1000, 0, 0, C_JMP), WantPC: 1000, Want: 101},                       // JMP 1000
+		{Inst: composeInst(1000, 0, 1, C_JMP), WantPC: 1000, Want: 0},                         // JSJ 1000
+		{Inst: composeInst(1000, 0, 2, C_JMP), Overflow: true, WantPC: 1000, Want: 101},       // JOV 1000
+		{Inst: composeInst(1000, 0, 2, C_JMP), WantPC: 101, Want: 0},                          // JOV 1000
+		{Inst: composeInst(1000, 0, 3, C_JMP), Overflow: true, WantPC: 101, Want: 0},          // JNOV 1000
+		{Inst: composeInst(1000, 0, 4, C_JMP), CI: [3]bool{true}, WantPC: 1000, Want: 101},    // JL 1000
+		{Inst: composeInst(1000, 0, 5, C_JMP), CI: [3]bool{true}, WantPC: 101, Want: 0},       // JE 1000
+		{Inst: composeInst(1000, 0, 7, C_JMP), CI: [3]bool{1: true}, WantPC: 1000, Want: 101}, // JGE 1000
+		{Inst: composeInst(1000, 0, 7, C_JMP), CI: [3]bool{2: true}, WantPC: 1000, Want: 101}, // JGE 1000
+		{Inst: composeInst(1000, 0, 8, C_JMP), CI: [3]bool{2: true}, WantPC: 1000, Want: 101}, // JNE 1000
+		{Inst: composeInst(1000, 0, 8, C_JMP), CI: [3]bool{1: true}, WantPC: 101, Want: 0},    // JNE 1000
+		{Inst: composeInst(1000, 0, 9, C_JMP), CI: [3]bool{true}, WantPC: 1000, Want: 101},    // JLE 1000
+		{Inst: composeInst(1000, 0, 0, C_JREG+A), RegVal: -5, WantPC: 1000, Want: 101},        // JAN 1000
+		{Inst: composeInst(1000, 0, 1, C_JREG+I3), RegVal: 5, WantPC: 101, Want: 0},           // J3Z 1000
+		{Inst: composeInst(1000, 0, 3, C_JREG+X), WantPC: 1000, Want: 101},                    // JXNN 1000
+		{Inst: composeInst(1000, 0, 5, C_JREG+I6), RegVal: 1, WantPC: 101, Want: 0},           // J6NP 1000
+	}
+	for _, test := range tests {
+		m := NewMachine()
+		m.PC, m.OverflowToggle = 101, test.Overflow
+		m.SetComparisons(test.CI[0], test.CI[1], test.CI[2])
+		if C_JMP < test.Inst.c() {
+			m.R[test.Inst.c()-C_JREG].w = test.RegVal
+		}
+		m.Exec(test.Inst)
+		if m.PC != test.WantPC || m.R[J].w != test.Want || m.OverflowToggle {
+			t.Errorf("\n%s\nWant: PC %v, rJ %v\nGot: PC %v, rJ %v, overflow %v\n",
+				test.Inst.instView(), test.WantPC, test.Want, m.PC, m.R[J].w, m.OverflowToggle)
+		}
+	}
+
+	m := NewMachine()
+	copy(m.Mem, []Word{
+		composeInst(2, 0, 0, C_JMP),     // JMP 2
+		composeInst(0, 0, 2, C_SPECIAL), // HLT
+		composeInst(1, 0, 0, C_JMP),     // JMP 1
+	})
+	if err := m.Run(context.Background()); err != nil || m.PC != 2 || m.R[J].w != 3 {
+		t.Errorf("want halt at 2 with rJ 3, got PC %v, rJ %v, %v", m.PC, m.R[J].w, err)
+	}
 }
 
-func TestShift(t *testing.T) {
-	t.Error("N/A")
+// TestAddressTransfer tests INC, DEC, ENT and ENN.
+func TestAddressTransfer(t *testing.T) {
+	tests := []struct {
+		Reg        RegState // to setup register
+		Inst, Want Word
+		Err        error
+	}{
+		{RegState{A, 7}, composeInst(1000, 0, 2, C_ADDR_TRANSFER), 1000, nil},          // ENTA 1000
+		{RegState{X, 7}, composeInst(1000, 0, 3, C_ADDR_TRANSFER+X), -1000, nil},       // ENNX 1000
+		{RegState{A, 7}, composeInst(1000, 0, 0, C_ADDR_TRANSFER), 1007, nil},          // INCA 1000
+		{RegState{I1, 5}, composeInst(10, 0, 1, C_ADDR_TRANSFER+I1), -5, nil},          // DEC1 10
+		{RegState{I2, 5}, composeInst(10, 2, 2, C_ADDR_TRANSFER+I2), 15, nil},          // ENT2 10,2
+		{RegState{I3, 100}, composeInst(4000, 0, 0, C_ADDR_TRANSFER+I3), 4, nil},       // INC3 4000, truncated
+		{RegState{I5, -4000}, composeInst(96, 0, 1, C_ADDR_TRANSFER+I5), negZero, nil}, // DEC5 96, truncated
+		{RegState{I4, 0}, -composeInst(4095, 0, 2, C_ADDR_TRANSFER+I4), -4095, nil},    // ENT4 -4095
+	}
+	for _, test := range tests {
+		m := NewMachine()
+		m.R[test.Reg.I].w = test.Reg.Data
+		err := m.Exec(test.Inst)
+		if result := m.R[test.Reg.I].w; test.Want != result || !errors.Is(err, test.Err) {
+			t.Errorf("\n%s\n%s\n%v | %v", test.Inst.instView(), wordDiff(test.Want, result), test.Err, err)
+		}
+	}
+
+	m := NewMachine()
+	copy(m.Mem, []Word{
+		composeInst(5, 0, 2, C_ADDR_TRANSFER+I1), // ENT1 5
+		composeInst(0, 0, 2, C_ADDR_TRANSFER),    // ENTA 0
+		composeInst(3, 0, 0, C_ADDR_TRANSFER),    // INCA 3
+		composeInst(1, 0, 1, C_ADDR_TRANSFER+I1), // DEC1 1
+		composeInst(2, 0, 2, C_JREG+I1),          // J1P 2
+		composeInst(0, 0, 2, C_SPECIAL),          // HLT
+	})
+	if err := m.Run(context.Background()); err != nil || m.R[A].w != 15 || m.R[I1].w != 0 {
+		t.Errorf("want rA 15 and rI1 0, got %v and %v, %v", m.R[A].w, m.R[I1].w, err)
+	}
 }
 
-func TestMove(t *testing.T) {
+// TestArithmetic tests ADD, SUB, INC and DEC around the overflow boundary.
+// The overflow toggle is only ever turned on by them.
+func TestArithmetic(t *testing.T) {
+	max := composeWord(63, 63, 63, 63, 63)
+	tests := []struct {
+		Reg            RegState
+		Cell           Word
+		Inst, Want     Word
+		WantOverflowed bool
+	}{
+		{RegState{A, 1000}, 234, composeInst(2000, 0, 5, C_ADD), 1234, false},                                            // ADD 2000
+		{RegState{A, max}, 1, composeInst(2000, 0, 5, C_ADD), 0, true},                                                   // ADD 2000
+		{RegState{A, 5}, -composeWord(1, 0, 0, 0, 3), composeInst(2000, 0, 5, C_SUB), composeWord(1, 0, 0, 0, 8), false}, // SUB 2000
+		{RegState{A, -max}, 2, composeInst(2000, 0, 5, C_SUB), -1, true},                                                 // SUB 2000
+		{RegState{A, max}, 0, composeInst(2, 0, 0, C_ADDR_TRANSFER), 1, true},                                            // INCA 2
+		{RegState{X, -max}, 0, composeInst(4, 0, 1, C_ADDR_TRANSFER+X), -3, true},                                        // DECX 4
+		{RegState{X, max}, 0, composeInst(4, 0, 1, C_ADDR_TRANSFER+X), max - 4, false},                                   // DECX 4
+	}
+	for _, test := range tests {
+		for _, overflow := range []bool{false, true} {
+			m := NewMachine()
+			m.OverflowToggle = overflow
+			m.R[test.Reg.I].w = test.Reg.Data
+			m.Write(2000, test.Cell)
+			m.Exec(test.Inst)
+			result := m.R[test.Reg.I].w
+			if test.Want != result || m.OverflowToggle != (overflow || test.WantOverflowed) {
+				t.Errorf("\n%s\n%s\nOverflow want %v, got %v", test.Inst.instView(), wordDiff(test.Want, result), overflow || test.WantOverflowed, m.OverflowToggle)
+			}
+		}
+	}
+}
+
+// TestNegZero tests instructions that produce or consume -0.
+func TestNegZero(t *testing.T) {
+	tests := []struct {
+		Reg        RegState
+		Cell       Word
+		Inst, Want Word
+	}{
+		{RegState{A, 5}, 0, composeInst(0, 0, 3, C_ADDR_TRANSFER), negZero},                     // ENNA 0
+		{RegState{A, 5}, 0, -composeInst(0, 0, 2, C_ADDR_TRANSFER), negZero},                    // ENTA -0
+		{RegState{I1, 5}, 0, -composeInst(0, 0, 3, C_ADDR_TRANSFER+I1), 0},                      // ENN1 -0
+		{RegState{A, 5}, -composeWord(1, 2, 3, 4, 5), composeInst(2000, 0, 0, C_LD), negZero},   // LDA 2000(0:0)
+		{RegState{X, 5}, composeWord(1, 2, 3, 4, 5), composeInst(2000, 0, 0, C_LDN+X), negZero}, // LDXN 2000(0:0)
+		{RegState{A, negZero}, 0, composeInst(2000, 0, 5, C_ADD), negZero},                      // ADD 2000
+		{RegState{A, -5}, 5, composeInst(2000, 0, 5, C_ADD), negZero},                           // ADD 2000
+		{RegState{A, negZero}, 7, composeInst(2000, 0, 5, C_SUB), -7},                           // SUB 2000
+		{RegState{A, negZero}, 7, composeInst(2000, 0, 5, C_MUL), negZero},                      // MUL 2000
+		{RegState{A, 0}, 7, composeInst(2000, 0, 0, C_MUL), 0},                                  // MUL 2000(0:0)
+		{RegState{I1, negZero}, 7, composeInst(2000, 0, 1, C_MOVE), 1},                          // MOVE 2000(1), to 0
+	}
+	for _, test := range tests {
+		m := NewMachine()
+		m.R[test.Reg.I].w = test.Reg.Data
+		m.Write(2000, test.Cell)
+		m.Exec(test.Inst)
+		if result := m.R[test.Reg.I].w; test.Want != result {
+			t.Errorf("\n%s\n%s", test.Inst.instView(), wordDiff(test.Want, result))
+		}
+	}
+
+	m := NewMachine()
+	m.R[A].w = negZero
+	m.Write(2000, composeWord(1, 2, 3, 4, 5))
+	m.Exec(composeInst(2000, 0, 0, C_ST)) // STA 2000(0:0)
+	if want := -composeWord(1, 2, 3, 4, 5); m.Read(2000) != want {
+		t.Error(wordDiff(want, m.Read(2000)))
+	}
+	m.Exec(composeInst(2001, 0, 5, C_ST)) // STA 2001
+	if m.Read(2001) != negZero {
+		t.Error(wordDiff(negZero, m.Read(2001)))
+	}
+	m.Exec(composeInst(2002, 0, 5, C_CMP)) // CMPA 2002
+	if lt, eq, gt := m.Comparisons(); lt || !eq || gt {
+		t.Error("want -0 == +0")
+	}
+	m.PC = 1
+	m.Exec(composeInst(1000, 0, 1, C_JREG)) // JAZ 1000
+	if m.PC != 1000 {
+		t.Error("want JAZ to jump on -0")
+	}
+	m.R[A].w, m.R[X].w = negZero, 17
+	m.Write(2003, 3)
+	m.Exec(composeInst(2003, 0, 5, C_DIV)) // DIV 2003
+	if m.R[A].w != -5 || m.R[X].w != -2 {
+		t.Errorf("want rA -5 and rX -2, got %v and %v", m.R[A].w.view(), m.R[X].w.view())
+	}
+}
+
+/*
+func TestComparison(t *testing.T) {
 	t.Error("N/A")
 }
 
@@ -193,8 +458,4 @@ func TestHalt(t *testing.T) {
 func TestIO(t *testing.T) {
 	t.Error("N/A")
 }
-
-func TestConversion(t *testing.T) {
-	t.Error("N/A")
-}
 */
@@ -1,272 +1,488 @@
 package main
 
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
 const (
 	C_ADD           = 1
 	C_SUB           = 2
 	C_MUL           = 3
 	C_DIV           = 4
+	C_SPECIAL       = 5 // NUM, CHAR, HLT
+	C_SHIFT         = 6
+	C_MOVE          = 7
 	C_LD            = 8
 	C_LDN           = 16
 	C_ST            = 24
+	C_IO            = 34 // JBUS, IOC, IN, OUT, JRED
+	C_JMP           = 39
+	C_JREG          = 40 // J_N, J_Z, ... for rA, rI1-rI6, rX
 	C_ADDR_TRANSFER = 48
 	C_CMP           = 56
 )
 
-func (m *Arch) Exec(inst Word) {
-	switch c := inst.c(); true {
-	case c == C_ADD:
-		m.Add(inst)
-	case c == C_SUB:
-		m.Add(inst)
-	case c == C_MUL:
-		m.Mul(inst)
-	case c == C_DIV:
-		m.Div(inst)
-	case C_LD <= c && c < C_ST:
-		m.Load(inst)
-	case C_ST <= c && c < C_CMP:
-		m.Store(inst)
-	case C_CMP <= c:
-		m.Compare(inst)
+var (
+	ErrIndex   = errors.New("exec: index register outside of [0, 6]")
+	ErrAddress = errors.New("exec: effective address outside of memory")
+	ErrOpcode  = errors.New("exec: undefined opcode")
+	ErrField   = errors.New("exec: invalid field for opcode")
+	ErrNoIO    = errors.New("exec: no device attached to unit")
+	ErrDivide  = errors.New("exec: quotient doesn't fit in rA")
+)
+
+// check returns the instruction with opcode c and field F,
+// or why this machine doesn't have it.
+func (m *Arch) check(c, F Word) (*Op, error) {
+	op, known := lookupOp(c, F)
+	switch {
+	case !known:
+		return nil, ErrOpcode
+	case op == nil:
+		return nil, ErrField
+	}
+	switch op.Needs {
+	case FloatingPoint:
+		if !m.floatingPoint {
+			return nil, ErrNoFloat
+		}
+	case BinaryExtensions:
+		if !m.binaryExt || m.b != Binary || m.strict {
+			return nil, ErrNoBinary
+		}
+	case InterruptFacility:
+		if !m.interrupts {
+			return nil, ErrNoInterrupts
+		}
+	}
+	switch op.FKind {
+	case FField:
+		if L, R := F/8, F%8; R < L || 5 < R {
+			return nil, ErrField
+		}
+	case FUnit:
+		if m.units[F] == nil {
+			return nil, ErrNoIO
+		}
 	}
+	return op, nil
+}
+
+// Address returns the effective address M of inst,
+// its address plus the contents of its index register.
+func (m *Arch) Address(inst Word) (Word, error) {
+	return m.address(m.b.a(inst), m.b.i(inst))
 }
 
-func (m *Arch) Add(inst Word) {
-	data := m.Read(inst.a()).slice(inst.fLR()).w
-	if inst.c() == 2 {
-		data = -data
+func (m *Arch) address(M, i Word) (Word, error) {
+	if 6 < i {
+		return 0, ErrIndex
 	}
-	m.R[A].w, m.OverflowToggle = m.R[A].w.add(data)
+	if i != 0 {
+		M += m.reg(i).value()
+	}
+	return M, nil
 }
 
-func (m *Arch) Mul(inst Word) {
-	v := m.Read(inst.a()).slice(inst.fLR()).w
-	sign, product := int64(1), int64(m.R[A].w)*int64(v)
-	if product < 0 {
-		sign, product = -1, -product
-	}
-	m.R[A].w = Word(sign * (product >> 30))
-	m.R[X].w = Word(sign * (product & 0x3FFFFFFF))
+// Exec executes inst as if it were the instruction at PC,
+// waiting for its unit first if it is busy.
+// A fault is returned as a *Fault and leaves the machine unchanged.
+func (m *Arch) Exec(inst Word) error {
+	d := m.decode(inst)
+	err := m.execAt(m.PC, &d)
+	if err == errWaited {
+		err = m.execAt(m.PC, &d)
+	}
+	return err
 }
 
-func (m *Arch) Div(inst Word) {
-	var q, r Word
-	den := int64(m.Read(inst.a()).slice(inst.fLR()).w)
-	if den != 0 {
-		num := int64(m.R[A].w.data())<<30 | int64(m.R[X].w.data())
-		q, r = Word(num/den), Word(num%den)
-	}
-	if den == 0 || q > (1<<31)-1 {
-		m.OverflowToggle = true
-		return
+// execAt executes d, the instruction at pc, and charges its time.
+// It returns errWaited, having charged only the wait, when d has
+// to run again once its unit is ready.
+func (m *Arch) execAt(pc Word, d *decoded) error {
+	control, start := m.Control, m.Time
+	err := m.exec(d)
+	if err != nil && err != errWaited {
+		return m.fault(err, pc, d.inst)
+	}
+	if err == nil {
+		m.Time += d.time
+		m.Executed++
 	}
-	m.R[X].w = m.R[A].w.sign() * r
-	m.R[A].w = q
+	if m.profile != nil {
+		c := m.profile[pc]
+		if err == nil {
+			c.Runs++
+		}
+		c.Time += m.Time - start
+		m.profile[pc] = c
+	}
+	if m.interrupts {
+		if !control {
+			m.tick(m.Time - start)
+		}
+		m.ioDone()
+	}
+	return err
 }
 
-/*type Convert struct {
-	fields MIXBytes
+func (m *Arch) exec(d *decoded) error {
+	if d.err != nil {
+		return d.err
+	}
+	if d.op.exec == nil { // NOP, which ignores its address and field
+		return nil
+	}
+	M, err := m.address(d.A, d.I)
+	if err != nil {
+		return err
+	}
+	if m.effect != nil {
+		m.effect.M = M
+	}
+	if d.op.Uses.location() && !m.inRange(M) {
+		return ErrAddress
+	}
+	return d.op.exec(m, d.inst, M)
 }
-func newConv(R MIXByte) *Convert {
-	return &Convert{defaultFields(0, R, 5)}
+
+// V returns field F of the cell at M, the operand of most instructions.
+func (m *Arch) V(inst, M Word) Word {
+	L, R := m.b.fLR(inst)
+	return m.b.get(m.Read(M), L, R)
 }
-// TODO:: conversions NUM and CHAR*/
 
-//func newShift(R MIXByte) *Shift {
-//	return &Shift{defaultFields(0, R, 6)}
-/*func (m *Arch) Shift(inst Word) {
-	buf, size := int64(m.R[A].w.data()), 5
-	_, R := inst.fLR()
-	if 1 < R { // shifts rA + rX (data only, not signs)
-		// keep sign gap in.w?
-		buf = (buf << 32) | m.R[X].w.data()
-		size += 5
-	}
-	var (
-		shiftAmt   = inst.a() % size
-		removed    int64 // make(MIXBytes, shiftAmt)
-		vacantMask Word  // needs to be int64 as well, just combine 2 masks for now
-	)
-	if R%2 == 0 { // left shift
-		// vacant = rData[size-shiftAmt:]
-		vacantMask = bitmask(size-shiftAmt, size-1)
-		// copy(removed, rData[:shiftAmt])
-		removed |= buf & bitmask(0, shiftAmt-1)
-		// copy(rData, rData[shiftAmt:])
-		// buf & (mask ^ 0x7FFFFFFF) | (buf & mask)
-	} else { // right shift
-		// vacant = rData[:shiftAmt]
-		vacantMask = bitmask(0, shiftAmt-1)
-		// copy(removed, rData[size-shiftAmt:])
-		removed |= buf & bitmask(size-shiftAmt, size-1)
-		// copy(rData[shiftAmt:], rData[:size-shiftAmt])
-	}
-	if 3 < R { // circular
-		//copy(vacant, removed)
-	} else {
-		//copy(vacant, make(MIXBytes, len(vacant)))
-	}
-	//copy(m.R[A].Data(), rData)
-	//copy(m.R[X].Data(), rData[5:]) // think nop if rX wasn't included in shift
-}*/
+func (m *Arch) Add(inst, M Word) {
+	data := m.V(inst, M)
+	if m.b.c(inst) == C_SUB {
+		data = data.neg()
+	}
+	sum, overflowed := m.b.add(m.reg(A), data)
+	m.setReg(A, sum)
+	if overflowed {
+		m.OverflowToggle = true
+	}
+}
+
+// Mul multiplies rA by V, leaving the 10 byte product in rAX.
+// Both registers take the algebraic sign of the product.
+func (m *Arch) Mul(inst, M Word) {
+	v := m.V(inst, M)
+	sign := m.reg(A).sign() * v.sign()
+	hi, lo := m.b.mul(m.reg(A), v)
+	m.setReg(A, signed(sign, hi))
+	m.setReg(X, signed(sign, lo))
+}
 
-/*func newMove(F MIXByte) *Move {
-	// weird to put F here, but once extracted, it will be "fine"
-	return &Move{fields: defaultFields(0, F, 7)}
+// Div divides rAX by V, leaving the quotient in rA and the remainder in rX.
+// rX takes the previous sign of rA. If V is 0 or the quotient needs more
+// than five bytes, it returns ErrDivide and leaves the registers alone.
+func (m *Arch) Div(inst, M Word) error {
+	v := m.V(inst, M)
+	den := uint64(v.data())
+	hi, lo := bits.Mul64(uint64(m.reg(A).data()), uint64(m.b.max()+1))
+	lo, carry := bits.Add64(lo, uint64(m.reg(X).data()), 0)
+	hi += carry
+	if den == 0 || den <= hi {
+		return ErrDivide
+	}
+	q, r := bits.Div64(hi, lo, den)
+	if uint64(m.b.max()) < q {
+		return ErrDivide
+	}
+	sign := m.reg(A).sign()
+	m.setReg(A, signed(sign*v.sign(), Word(q)))
+	m.setReg(X, signed(sign, Word(r)))
+	return nil
 }
-func (inst *Move) Effect(m *MIXArch) *Snapshot {
-	snapshot := new(Snapshot)
-	L, R := FieldSpec(inst)
-	mvAmt, srcI, dstI := int(8*L+R), toNum(Address(inst)), toNum(m.R[I1].Raw())
-	if srcI != dstI { // nop otherwise, copies cell to itself
-		for i := 0; i < mvAmt; i++ {
-			copy(m.Mem[dstI+i], m.Mem[srcI+i])
-			defer func() { snapshot.includesCell(dstI+i, m.Mem[dstI+i]) }()
+
+// Num sets the magnitude of rA to the 10 digit decimal number held as
+// character codes in rAX, keeping the remainder mod b^5 on overflow.
+// The sign of rA and all of rX are unchanged.
+func (m *Arch) Num() {
+	var v Word
+	for _, r := range []Word{m.reg(A).data(), m.reg(X).data()} {
+		for i := Word(1); i <= WORDSIZE; i++ {
+			v = 10*v + m.b.field(r, i, i)%10
 		}
-		copy(m.R[I1], toMIXBytes(dstI+mvAmt, 2))
-		defer func() { snapshot.includesR(I1, m.R[I1]) }()
 	}
-	return snapshot
-}*/
+	m.setReg(A, signed(m.reg(A).sign(), v%(m.b.max()+1)))
+}
 
-func (m *Arch) Load(inst Word) {
-	rI, data := inst.c()-C_LD, m.Read(inst.a())
-	if C_LDN <= inst.c() {
-		rI, data = inst.c()-C_LDN, -data
+// Char converts the magnitude of rA to 10 decimal digits in character
+// code, the first 5 in rA and the rest in rX. Signs are unchanged.
+func (m *Arch) Char() {
+	v := m.reg(A).data()
+	var digits [2 * WORDSIZE]Word
+	for i := len(digits) - 1; 0 <= i; i-- {
+		digits[i], v = 30+v%10, v/10
 	}
-	regSlice := m.R[rI]
-	if regSlice.w.sign() != data.sign() { // s is either positive bc L != 0 or data's sign
-		regSlice.w *= -1
+	m.setReg(A, signed(m.reg(A).sign(), m.b.word(digits[0], digits[1], digits[2], digits[3], digits[4])))
+	m.setReg(X, signed(m.reg(X).sign(), m.b.word(digits[5], digits[6], digits[7], digits[8], digits[9])))
+}
+
+var ErrShift = errors.New("exec: negative shift amount")
+
+// Shift shifts rA (SLA, SRA) or rAX (SLAX, SRAX, SLC, SRC) by M bytes,
+// or rAX by M bits (SLB, SRB).
+// Only data is shifted, the signs of rA and rX are left alone.
+func (m *Arch) Shift(inst, M Word) error {
+	if M < 0 {
+		return ErrShift
+	}
+	F := m.b.f(inst)
+	if 5 < F {
+		m.shiftBits(F, M)
+		return nil
+	}
+	var buf, shifted [2 * WORDSIZE]Word // rA + rX as one 10 byte buffer
+	for i := Word(1); i <= WORDSIZE; i++ {
+		buf[i-1] = m.b.field(m.reg(A).data(), i, i)
+		buf[WORDSIZE+i-1] = m.b.field(m.reg(X).data(), i, i)
+	}
+	size := Word(WORDSIZE)
+	if 1 < F {
+		size *= 2
 	}
-	regSlice.copy(data.slice(inst.fLR()))
+	for i := Word(0); i < size; i++ {
+		var from Word
+		switch F {
+		case 0, 2: // SLA, SLAX
+			from = i + M
+		case 1, 3: // SRA, SRAX
+			from = i - M
+		case 4: // SLC
+			from = (i + M) % size
+		case 5: // SRC
+			from = (i + size - M%size) % size
+		}
+		if 0 <= from && from < size {
+			shifted[i] = buf[from]
+		}
+	}
+	m.setReg(A, signed(m.reg(A).sign(), m.b.word(shifted[0], shifted[1], shifted[2], shifted[3], shifted[4])))
+	if 1 < F {
+		m.setReg(X, signed(m.reg(X).sign(), m.b.word(shifted[5], shifted[6], shifted[7], shifted[8], shifted[9])))
+	}
+	return nil
 }
 
-func (m *Arch) Store(inst Word) {
-	regS := Word(0).slice(0, 5) // STZ
-	if inst.c() < 33 {
-		regS = m.R[inst.c()-C_ST].w.slice(0, 5)
-	}
-	L, R := inst.fLR()
-	cell := m.Read(inst.a())
-	buf := Word(0).slice(L, R).copy(regS)
-	m.Write(inst.a(), buf.apply(cell))
+// Move copies F words starting at M to the location in rI1,
+// one word at a time so overlapping ranges behave like Knuth's MIX.
+// rI1 is then increased by F, past two bytes only as INC1 would.
+func (m *Arch) Move(inst, M Word) error {
+	F, dst := m.b.f(inst), m.reg(I1).value()
+	if 0 < F && !(m.inRange(M+F-1) && m.inRange(dst) && m.inRange(dst+F-1)) {
+		return ErrAddress
+	}
+	next := dst + F
+	if limit := m.b.pow(m.R[I1].len); limit <= next {
+		if m.strict {
+			return ErrIndexOverflow
+		}
+		next %= limit
+	}
+	for i := Word(0); i < F; i++ {
+		m.Write(dst+i, m.Read(M+i))
+	}
+	m.setReg(I1, next)
+	return nil
 }
 
-/*type IO struct {
-	fields MIXBytes
+// Load loads the field of the cell at M (LD) or its negative (LDN)
+// into a register, which is positive unless the field has the sign.
+// An index register keeps the last two bytes of the field, or in
+// strict mode refuses a field that needs more.
+func (m *Arch) Load(inst, M Word) error {
+	c := m.b.c(inst)
+	L, R := m.b.fLR(inst)
+	rI, field := c-C_LD, m.b.get(m.Read(M), L, R)
+	if C_LDN <= c {
+		rI, field = c-C_LDN, field.neg()
+	}
+	reg := m.R[rI]
+	if m.strict && m.b.pow(reg.len) <= field.data() {
+		return ErrIndexOverflow
+	}
+	m.setReg(rI, signed(field.sign(), field.data()%m.b.pow(reg.len)))
+	return nil
 }
 
-func newIO(R, c MIXByte) *IO {
-	return &IO{defaultFields(0, R, c)}
+func (m *Arch) Store(inst, M Word) {
+	var v Word // STZ
+	if c := m.b.c(inst); c < 33 {
+		v = m.reg(c - C_ST)
+	}
+	L, R := m.b.fLR(inst)
+	m.Write(M, m.b.set(m.peek(M), L, R, v))
 }
-func (inst *IO) Effect(m *MIXArch) *Snapshot {
-	/*
-		// Involves rX somehow with posiitoning device
-		L, R := FieldSpec(inst)
-		// need to attach device to machine
-		device := m.Devices[8*L+R]
-		start := toNum(Address(inst))
-		end := start + len(device) + 1
-		switch Code(inst) {
-		case 35: // IOC
-		m.Devices.Control(device) or device.Reset if behavior is pretty uniform
-		case 36: // IN
-		// Not really what I want, copies over MIXBytes
-		copy(m.Mem[start:end], device)
-		// make some device.Write(m.Mem, start)
-		case 37: // OUT
-		// device.Read(m.Mem, start)
-		case 34: // JBUS
-		case 38: // JRED
-		}
 
-	return new(Snapshot)
-}*/
+// errWaited is returned by IO when an IN, OUT or IOC found its unit busy
+// and waited for it: the time is charged and the instruction runs again.
+var errWaited = errors.New("exec: waited for busy unit")
+
+// IO runs the I/O instruction inst on unit F. JBUS and JRED jump when the
+// unit is busy or ready. IN, OUT and IOC wait for the unit to be ready,
+// see errWaited, then keep it busy for the latency of its device.
+func (m *Arch) IO(inst, M Word) error {
+	c, u := m.b.c(inst), m.units[m.b.f(inst)]
+	busy := m.Time < u.ready
+	switch c {
+	case C_IO: // JBUS
+		if busy {
+			m.setReg(J, m.PC)
+			m.PC = M
+		}
+		return nil
+	case C_IO + 4: // JRED
+		if !busy {
+			m.setReg(J, m.PC)
+			m.PC = M
+		}
+		return nil
+	}
+	if busy {
+		m.Time = u.ready
+		return errWaited
+	}
+	x := m.reg(X).value()
+	if s, ok := u.dev.(*Storage); ok && m.effect != nil {
+		m.effect.undo = append(m.effect.undo, undoStorage(s, c == C_IO+3, x))
+	}
+	var err error
+	switch c {
+	case C_IO + 1: // IOC
+		err = u.dev.Control(M, x)
+	case C_IO + 2: // IN
+		block := make([]Word, u.dev.BlockSize())
+		if !m.inRange(M + Word(len(block)) - 1) {
+			return ErrAddress
+		}
+		if err = u.dev.In(block, x); err == nil {
+			for i, w := range block {
+				m.Write(M+Word(i), w)
+			}
+		}
+	case C_IO + 3: // OUT
+		block := make([]Word, u.dev.BlockSize())
+		if !m.inRange(M + Word(len(block)) - 1) {
+			return ErrAddress
+		}
+		for i := range block {
+			block[i] = m.Read(M + Word(i))
+		}
+		err = u.dev.Out(block, x)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDevice, err)
+	}
+	u.ready, u.notify = m.Time+1+u.latency, m.interrupts
+	return nil
+}
 
-func (m *Arch) Jump(inst Word) {
-	_, R := inst.fLR()
-	c, address := inst.c(), inst.a()
+// Jump sets PC to M when the condition of inst holds. Except for JSJ,
+// a jump also sets rJ to PC, which the run loop has already advanced
+// to the instruction after inst.
+func (m *Arch) Jump(inst, M Word) {
+	c, F := m.b.c(inst), m.b.f(inst)
 
 	// comparison flags and values are gathered
 	// here to avoid repeating later.
 	lt, eq, gt := m.Comparisons()
 	var v Word
-	if 39 < inst.c() {
-		rI := inst.c() - 40
-		v = m.R[rI].w
+	if C_JMP < c {
+		v = m.reg(c - C_JREG).value()
 	}
 
-	// Jumping seems to consist of writing to
-	// rJ and PC.
+	// Jumping consists of writing to rJ and PC.
 	_setJmp := func() {
-		m.R[J].copy(Word(address).slice(0, 5))
-		m.PC = address
+		m.setReg(J, m.PC)
+		m.PC = M
 	}
 
 	switch true {
-	case c == 39 && R == 0: // JMP
+	case c == C_JMP && F == 0: // JMP
 		_setJmp()
-	case c == 39 && R == 1: // JSJ
-		m.PC = address
-	case c == 39 && R == 2: // JOV
+	case c == C_JMP && F == 1: // JSJ
+		m.PC = M
+	case c == C_JMP && F == 2: // JOV
 		if m.OverflowToggle {
 			_setJmp()
 		}
 		m.OverflowToggle = false
-	case c == 39 && R == 3: // JNOV
+	case c == C_JMP && F == 3: // JNOV
 		if !m.OverflowToggle {
 			_setJmp()
 		}
 		m.OverflowToggle = false
-	case c == 39 && R == 4 && lt: // JL
+	case c == C_JMP && F == 4 && lt: // JL
+		_setJmp()
+	case c == C_JMP && F == 5 && eq: // JE
+		_setJmp()
+	case c == C_JMP && F == 6 && gt: // JG
 		_setJmp()
-	case c == 39 && R == 5 && eq: // JE
+	case c == C_JMP && F == 7 && (gt || eq): // JGE
 		_setJmp()
-	case c == 39 && R == 6 && gt: // JG
+	case c == C_JMP && F == 8 && (lt || gt): // JNE
 		_setJmp()
-	case c == 39 && R == 7 && eq && gt: // JGE
+	case c == C_JMP && F == 9 && (lt || eq): // JLE
 		_setJmp()
-	case c == 39 && R == 8 && lt && gt: // JNE
+	case C_JMP < c && F == 0 && v < 0: // J_N
 		_setJmp()
-	case c == 39 && R == 9 && lt && eq: // JLE
+	case C_JMP < c && F == 1 && v == 0: // J_Z
 		_setJmp()
-	case 39 < c && R == 0 && v < 0: // J_N
+	case C_JMP < c && F == 2 && 0 < v: // J_P
 		_setJmp()
-	case 39 < c && R == 1 && v == 0: // J_Z
+	case C_JMP < c && F == 3 && -1 < v: // J_NN
 		_setJmp()
-	case 39 < c && R == 2 && 0 < v: // J_P
+	case C_JMP < c && F == 4 && v != 0: // J_NZ
 		_setJmp()
-	case 39 < c && R == 3 && -1 < v: // J_NN
+	case C_JMP < c && F == 5 && v < 1: // J_NP
 		_setJmp()
-	case 39 < c && R == 4 && v != 0: // J_NZ
+	case C_JMP < c && F == 6 && v%2 == 0: // JAE, JXE
 		_setJmp()
-	case 39 < c && R == 5 && v < 1: // J_NP
+	case C_JMP < c && F == 7 && v%2 != 0: // JAO, JXO
 		_setJmp()
 	}
 }
 
-//func newAddressTransfer(R, c, rI MIXByte) *AddressTransfer {
-func (m *Arch) AddressTransfer(inst Word) {
-	rI := inst.c() - C_ADDR_TRANSFER
-	_, R := inst.fLR()
-	address := inst.a()
-	if R%2 == 1 { // DEC, ENN
-		address = -address
-	}
-	dst := m.R[rI]
-	if R < 2 { // INC, DEC
-		// but is the slice state stable/correct?
-		dst.w, m.OverflowToggle = dst.w.add(address)
-	} else { // ENT, ENN
-		// does this work for I1-I6, J?
-		dst.copy(Word(address).slice(0, 5))
+var ErrIndexOverflow = errors.New("exec: index register needs more than two bytes")
+
+// AddressTransfer increases (INC), decreases (DEC), sets (ENT)
+// or sets the negative (ENN) of a register with M.
+// rA and rX overflow like ADD. An index register keeps the last two
+// bytes of the result, or in strict mode refuses a result that needs more.
+func (m *Arch) AddressTransfer(inst, M Word) error {
+	rI, F := m.b.c(inst)-C_ADDR_TRANSFER, m.b.f(inst)
+	if M == 0 { // ENT and ENN load the sign of inst
+		M = signed(inst.sign(), 0)
+	}
+	if F%2 == 1 { // DEC, ENN
+		M = M.neg()
+	}
+	v := M     // ENT, ENN
+	if F < 2 { // INC, DEC
+		var overflowed bool
+		if v, overflowed = m.b.add(m.reg(rI), M); overflowed && (rI == A || rI == X) {
+			m.OverflowToggle = true
+		}
+	}
+	if reg := m.R[rI]; rI != A && rI != X && m.b.pow(reg.len) <= v.data() {
+		if m.strict {
+			return ErrIndexOverflow
+		}
+		v = signed(v.sign(), v.data()%m.b.pow(reg.len))
 	}
+	m.setReg(rI, v)
+	return nil
 }
 
-func (m *Arch) Compare(inst Word) {
-	rI := inst.c() - C_CMP
-	L, R := inst.fLR()
-	regVal := m.R[rI].w.slice(L, R).w
-	cellVal := m.Read(inst.a()).slice(L, R).w
+func (m *Arch) Compare(inst, M Word) {
+	rI := m.b.c(inst) - C_CMP
+	L, R := m.b.fLR(inst)
+	regVal := m.b.get(m.reg(rI), L, R).value()
+	cellVal := m.V(inst, M).value()
 	m.SetComparisons(regVal < cellVal, regVal == cellVal, regVal > cellVal)
 }
@@ -0,0 +1,104 @@
+package main
+
+import "errors"
+
+// Interrupt facility (TAOCP exercise 1.4.4-18). In control state the
+// locations -3999 through -1 can be used as well. An interrupt saves
+// the registers at -10 through -1, enters control state and jumps to -12.
+// INT returns to normal state, restoring the registers.
+//
+//	-12      interrupt handler
+//	-11      timer, decreased by the time of each instruction
+//	         run in normal state, interrupting when it reaches 0
+//	-10      rA
+//	-9..-4   rI1..rI6
+//	-3       rX
+//	-2       rJ
+//	-1       PC in (4:5), overflow toggle in (3:3),
+//	         comparison indicator in (2:2): 1 <, 2 =, 3 >
+const (
+	intHandler = -12
+	intTimer   = -11
+	intSave    = -10
+)
+
+var ErrNoInterrupts = errors.New("exec: interrupt facility not installed")
+
+// inRange reports whether M is a location the machine can use now.
+func (m *Arch) inRange(M Word) bool {
+	if M < 0 {
+		return m.Control && -Word(len(m.control)) < M
+	}
+	return M < Word(len(m.Mem))
+}
+
+// Interrupt requests an interrupt, taken before the next instruction
+// run in normal state. I/O units interrupt when they finish.
+// It is safe to call while Run goes.
+func (m *Arch) Interrupt() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending = m.interrupts
+}
+
+// interrupt saves the registers and enters control state at -12.
+func (m *Arch) interrupt() {
+	for r := A; r <= J; r++ {
+		m.Write(intSave+Word(r), m.reg(Word(r)))
+	}
+	var ci Word
+	switch {
+	case m.ComparisonIndicator.Less:
+		ci = 1
+	case m.ComparisonIndicator.Equal:
+		ci = 2
+	case m.ComparisonIndicator.Greater:
+		ci = 3
+	}
+	var ov Word
+	if m.OverflowToggle {
+		ov = 1
+	}
+	m.Write(-1, m.b.word(0, ci, ov, m.PC/Word(m.b), m.PC))
+	m.Control, m.pending = true, false
+	m.PC = intHandler
+}
+
+// Int (INT) leaves control state, restoring the registers saved
+// when the interrupt was taken. In normal state it interrupts.
+func (m *Arch) Int() {
+	if !m.Control {
+		m.interrupt()
+		return
+	}
+	for r := A; r <= J; r++ {
+		m.setReg(Word(r), m.Read(intSave+Word(r)))
+	}
+	saved := m.Read(-1)
+	ci := m.b.field(saved.data(), 2, 2)
+	m.SetComparisons(ci == 1, ci == 2, ci == 3)
+	m.OverflowToggle = m.b.field(saved.data(), 3, 3) == 1
+	m.PC = m.b.field(saved.data(), 4, 5)
+	m.Control = false
+}
+
+// tick runs the timer at -11 for an instruction that took t in normal state.
+func (m *Arch) tick(t Word) {
+	if timer := m.Read(intTimer); 0 < timer {
+		if timer <= t {
+			timer, m.pending = 0, true
+		} else {
+			timer -= t
+		}
+		m.Write(intTimer, timer)
+	}
+}
+
+// ioDone interrupts for each unit that finished an operation.
+func (m *Arch) ioDone() {
+	for _, u := range m.units {
+		if u.notify && u.ready <= m.Time {
+			u.notify, m.pending = false, true
+		}
+	}
+}
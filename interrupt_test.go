@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestInterrupt(t *testing.T) {
+	m := NewMachine(WithInterrupts())
+	copy(m.Mem, []Word{
+		composeInst(1, 0, 0, C_ADDR_TRANSFER), // INCA 1
+		composeInst(0, 0, 0, C_JMP),           // JMP 0
+	})
+	m.Write(-12, -composeInst(100, 0, 0, C_JMP)) // JMP -100
+	for i, inst := range []Word{
+		-composeInst(3, 0, 5, C_LD+X),           // LDX -3
+		composeInst(1, 0, 0, C_ADDR_TRANSFER+X), // INCX 1
+		-composeInst(3, 0, 5, C_ST+X),           // STX -3
+		composeInst(5, 0, 2, C_ADDR_TRANSFER),   // ENTA 5
+		-composeInst(11, 0, 5, C_ST),            // STA -11
+		-composeInst(11, 0, 5, C_CMP),           // CMPA -11
+		composeInst(0, 0, 9, C_SPECIAL),         // INT
+	} {
+		m.Write(Word(-100+i), inst)
+	}
+	m.Write(-11, 5) // timer
+	m.OverflowToggle = true
+	m.SetComparisons(false, false, true)
+
+	step := func(n int) {
+		for ; 0 < n; n-- {
+			if err := m.Step(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	step(5)
+	if m.Control || !m.pending {
+		t.Fatalf("want timer interrupt pending, got control %v, pending %v", m.Control, m.pending)
+	}
+	step(1) // interrupt, JMP -100
+	if !m.Control || m.PC != -100 || m.Read(-10) != 3 || m.b.field(m.Read(-1), 4, 5) != 1 {
+		t.Fatalf("want control state at -100 with rA 3 and PC 1 saved, got %v, %v, %v, %v",
+			m.Control, m.PC, m.Read(-10), m.Read(-1).view())
+	}
+	step(7)
+	lt, eq, gt := m.Comparisons()
+	if m.Control || m.PC != 1 || m.R[A].w != 3 || m.R[X].w != 1 || !m.OverflowToggle || lt || eq || !gt {
+		t.Errorf("want normal state at 1 with rA 3, rX 1, overflow and >, got %v, %v, %v, %v, %v, %v",
+			m.Control, m.PC, m.R[A].w, m.R[X].w, m.OverflowToggle, [3]bool{lt, eq, gt})
+	}
+	if m.Read(-11) != 5 {
+		t.Errorf("want timer 5, got %v", m.Read(-11))
+	}
+
+	m.Interrupt()
+	step(1)
+	if !m.Control || m.PC != -100 {
+		t.Errorf("want control state at -100, got %v, %v", m.Control, m.PC)
+	}
+
+	m = NewMachine(WithInterrupts())
+	if err := m.Exec(-composeInst(1, 0, 5, C_LD)); !errors.Is(err, ErrAddress) { // LDA -1
+		t.Errorf("normal state: want %v, got %v", ErrAddress, err)
+	}
+	if err := NewMachine().Exec(composeInst(0, 0, 9, C_SPECIAL)); !errors.Is(err, ErrNoInterrupts) { // INT
+		t.Errorf("want %v, got %v", ErrNoInterrupts, err)
+	}
+}
+
+func TestIOInterrupt(t *testing.T) {
+	m := NewMachine(WithInterrupts(), WithDevice(TAPE0, NewTape(), 3))
+	m.Mem[0] = composeInst(1000, 0, TAPE0, C_IO+3) // OUT 1000(0), NOPs follow
+	for !m.Control && m.PC < 10 {
+		if err := m.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if saved := m.b.field(m.Read(-1), 4, 5); !m.Control || saved != 4 {
+		t.Errorf("want interrupt at 4 when the tape is ready, got control %v at %v", m.Control, saved)
+	}
+}
+
+// TestInterruptWhileRunning interrupts a running machine from another
+// goroutine, for go test -race.
+func TestInterruptWhileRunning(t *testing.T) {
+	m := NewMachine(WithInterrupts())
+	m.Mem[0] = composeInst(0, 0, 0, C_JMP)        // JMP 0
+	m.Write(-12, composeInst(0, 0, 2, C_SPECIAL)) // HLT
+	done := make(chan error)
+	go func() { done <- m.Run(context.Background()) }()
+	m.Interrupt()
+	if err := <-done; err != nil || m.State != Halted || !m.Control {
+		t.Errorf("want halted in control state, got %v, %v, %v", m.State, m.Control, err)
+	}
+}
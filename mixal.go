@@ -3,25 +3,38 @@ package main
 import (
 	"bufio"
 	"errors"
-	"fmt"
 	"io"
-	"regexp"
 	"strconv"
+	"strings"
 )
 
 type Assembler struct {
-	locCtr        Word
-	knownSyms     map[string]Word
-	futureRefs    map[string][]Word
-	literalConsts []Word
-	mixalRe       *regexp.Regexp
+	locCtr     Word
+	knownSyms  map[string]Word
+	futureRefs map[string][]Word
+	literals   []literalRef // waiting for END to place them
+	b          ByteSize     // of the machine assembled for
+	Lines      []SourceLine // the source assembled, in order
+}
+
+// literalRef is a literal constant and the location of the instruction
+// using it, whose address END sets to where it places the constant.
+type literalRef struct {
+	value, loc Word
+}
+
+// SourceLine is a line of MIXAL and the location it was assembled to.
+type SourceLine struct {
+	Text string
+	Loc  Word // -1 for lines that don't fill a location
+	Inst bool // assembled to an instruction
 }
 
 func NewAssembler() *Assembler {
 	return &Assembler{
 		knownSyms:  make(map[string]Word),
 		futureRefs: make(map[string][]Word),
-		mixalRe:    regexp.MustCompile(`(.+\s)?(.+)\s(.+)`),
+		b:          Binary,
 	}
 }
 
@@ -38,7 +51,7 @@ func findChar(s string, target byte, from int) (pos int) {
 
 var (
 	ErrSymLen    = errors.New("symbol: 0 or more than 10 characters")
-	ErrSymSyntax = errors.New("symbol: contains non-digit or non-capital letter")
+	ErrSymSyntax = errors.New("symbol: not digits and capital letters with at least one letter")
 	ErrFutureRef = errors.New("symbol: future reference")
 )
 
@@ -47,10 +60,17 @@ func (a *Assembler) symbol(s string) (Word, error) {
 	if len(s) == 0 || 10 < len(s) {
 		return 0, ErrSymLen
 	}
+	letters := 0
 	for _, c := range s {
 		if !isDigit(c) && !isLetter(c) {
 			return 0, ErrSymSyntax
 		}
+		if isLetter(c) {
+			letters++
+		}
+	}
+	if letters == 0 {
+		return 0, ErrSymSyntax
 	}
 	v, known := a.knownSyms[s]
 	if !known {
@@ -89,9 +109,7 @@ func (a *Assembler) literal(s string) (Word, error) {
 	if s[0] != '=' || s[len(s)-1] != '=' {
 		return 0, ErrLiteralSyntax
 	}
-	v, err := a.wValue(s[1 : len(s)-1])
-	a.literalConsts = append(a.literalConsts, v)
-	return v, err
+	return a.wValue(s[1 : len(s)-1])
 }
 
 var ErrNonUnaryOp = errors.New("unaryOp: not a unary op")
@@ -104,7 +122,7 @@ func (a *Assembler) unaryOp(s string) (Word, error) {
 			return 0, err
 		}
 		if unaryOp == '-' {
-			v = -v
+			v = v.neg()
 		}
 		return v, nil
 	}
@@ -132,6 +150,7 @@ func (a *Assembler) binaryOp(s string) (Word, error) {
 	if exprErr != nil {
 		return 0, exprErr
 	}
+	atomVal, exprVal = atomVal.value(), exprVal.value()
 	switch op {
 	case "+":
 		return exprVal + atomVal, nil
@@ -149,29 +168,42 @@ func (a *Assembler) binaryOp(s string) (Word, error) {
 	return 0, ErrNonBinaryOp
 }
 
+// Assemble assembles the MIXAL in src into the memory of m and returns
+// the start address given by END. A line is LOC OP ADDRESS followed by
+// remarks, LOC left out when the line starts with a space. END places
+// literal constants, =W=, after the program.
 func (a *Assembler) Assemble(m *Arch, src io.Reader) (startAddress Word, err error) {
+	a.b = m.b
 	line := bufio.NewScanner(src)
 	for line.Scan() {
-		if line.Text()[0] == '*' {
+		text := line.Text()
+		a.Lines = append(a.Lines, SourceLine{Text: text, Loc: -1})
+		if strings.TrimSpace(text) == "" || text[0] == '*' {
 			continue
 		}
-		matches := a.mixalRe.FindStringSubmatch(line.Text())
-		if matches == nil {
+		fields := strings.Fields(text)
+		var sym, op, address string
+		if text[0] != ' ' && text[0] != '\t' {
+			sym, fields = fields[0], fields[1:]
+		}
+		if len(fields) == 0 {
 			return -1, errors.New("not a mixal line")
 		}
-		sym, op, address := matches[1], matches[2], matches[3]
-		fmt.Println(sym, op, address)
+		op = fields[0]
+		if 1 < len(fields) {
+			address = fields[1]
+		}
 
 		if sym != "" {
-			if _, err := a.symbol(sym); err != nil {
+			if _, err := a.symbol(sym); err != nil && err != ErrFutureRef {
 				return -1, err
 			}
 			a.knownSyms[sym] = a.locCtr
 			if locs, ok := a.futureRefs[sym]; ok { // check if sym was in futureRefs
 				delete(a.futureRefs, sym)
-				notMask := bitmask(1, 2) ^ 0x3FFFFFFF
+				address := &bitslice{a.locCtr, 1, 2, a.b}
 				for _, loc := range locs {
-					m.Mem[loc] = (m.Mem[loc] & notMask) | (a.locCtr << 18)
+					m.Mem[loc] = address.apply(m.Mem[loc])
 				}
 			}
 		}
@@ -184,29 +216,117 @@ func (a *Assembler) Assemble(m *Arch, src io.Reader) (startAddress Word, err err
 			}
 		}
 
+		src := &a.Lines[len(a.Lines)-1]
 		switch op {
 		case "EQU":
 			a.knownSyms[sym] = v // refer to comment below
 		case "ORIG":
 			a.locCtr = v
 		case "CON":
-			m.Mem[a.locCtr] = v
-			a.locCtr++
+			if err := a.emit(m, v); err != nil {
+				return -1, err
+			}
+			src.Loc = a.locCtr - 1
 		case "ALF":
 			// assemble address[:5] as alphanumeric char MIX word
 			// use Convert operators??
 			// m.Mem[a.locCtr] = v
 			a.locCtr++
 		case "END":
-			// process each recorded constant as CON
 			// also would have something similar for unknown syms
 			// if sym != "" ...
-			m.Mem[a.locCtr] = v
+			for _, l := range a.literals { // as CON after the program
+				address := &bitslice{a.locCtr, 1, 2, a.b}
+				if err := a.emit(m, l.value); err != nil {
+					return -1, err
+				}
+				m.Mem[l.loc] = address.apply(m.Mem[l.loc])
+			}
+			a.literals = nil
+			startAddress = v
 		default:
-			// ParseInst logic here
+			inst, err := a.inst(op, address)
+			if err != nil {
+				return -1, err
+			}
+			if err := a.emit(m, inst); err != nil {
+				return -1, err
+			}
+			src.Loc, src.Inst = a.locCtr-1, true
+		}
+	}
+	if len(a.literals) != 0 {
+		return -1, ErrNoEnd
+	}
+	return startAddress, line.Err()
+}
+
+var ErrNoEnd = errors.New("assemble: literal constants without END to place them")
+
+var ErrLocation = errors.New("assemble: location outside of memory")
+
+// emit puts w at the location counter and advances it.
+func (a *Assembler) emit(m *Arch, w Word) error {
+	if a.locCtr < 0 || Word(len(m.Mem)) <= a.locCtr {
+		return ErrLocation
+	}
+	m.Mem[a.locCtr] = w
+	a.locCtr++
+	return nil
+}
+
+var (
+	ErrUnknownOp    = errors.New("inst: unknown operation")
+	ErrAddressRange = errors.New("inst: address needs more than two bytes")
+	ErrIndexRange   = errors.New("inst: index register outside of [0, 6]")
+	ErrFieldRange   = errors.New("inst: field needs more than one byte")
+)
+
+// inst assembles OP ADDRESS,I(F), F defaulting to the normal one for op.
+// Parts that don't fit the instruction are refused, not wrapped.
+func (a *Assembler) inst(op, operand string) (Word, error) {
+	template, ok := opsByName[op]
+	if !ok {
+		return 0, ErrUnknownOp
+	}
+	from := 0 // past a literal constant, which may hold ( and ,
+	if operand != "" && operand[0] == '=' {
+		from = findChar(operand, '=', 1) + 1
+	}
+	endA, endI := len(operand), len(operand)
+	if open := findChar(operand, '(', from); 0 <= open {
+		endA, endI = open, open
+	}
+	if comma := findChar(operand[:endA], ',', from); 0 <= comma {
+		endA = comma
+	}
+	A, err := a.a(operand[:endA])
+	if err != nil {
+		return 0, err
+	}
+	I, err := a.i(operand[endA:endI])
+	if err != nil {
+		return 0, err
+	}
+	F := template.F
+	if endI < len(operand) {
+		if F, err = a.f(operand[endI:]); err != nil {
+			return 0, err
 		}
 	}
-	return 0, line.Err()
+	switch {
+	case a.b.pow(2) <= A.data():
+		return 0, ErrAddressRange
+	case I < 0 || 6 < I:
+		return 0, ErrIndexRange
+	case F < 0 || Word(a.b) <= F:
+		return 0, ErrFieldRange
+	}
+	inst := a.b.inst(A.data(), I, F, template.C)
+	if A < 0 {
+		inst = -inst
+	}
+	return inst, nil
 }
 
 // does it add to instruction slice in assembler?
@@ -246,9 +366,9 @@ func (a *Assembler) a(s string) (Word, error) {
 		a.futureRefs[s] = append(a.futureRefs[s], a.locCtr)
 		return 0, nil
 	}
-	if v, err := a.literal(s); err == nil { // literal constant
-		// create internal sym, kind of a future ref too
-		return v, nil
+	if v, err := a.literal(s); err == nil { // literal constant, placed by END
+		a.literals = append(a.literals, literalRef{v, a.locCtr})
+		return 0, nil
 	}
 	if v, err := a.expression(s); err == nil { // expression
 		return v, nil
@@ -269,7 +389,7 @@ func (a *Assembler) i(s string) (Word, error) {
 func (a *Assembler) f(s string) (Word, error) {
 	switch true {
 	case s == "":
-		return 5, nil // this is wrong, depends on the normal F spec for the op
+		return 5, nil // (0:5) for W-values, instructions take theirs from Ops
 	case s[0] == '(' && ')' == s[len(s)-1]:
 		return a.expression(s[1 : len(s)-1])
 	}
@@ -294,8 +414,8 @@ func (a *Assembler) wValue(s string) (v Word, err error) {
 		if err != nil {
 			return 0, err
 		}
-		L, R := composeInst(0, 0, fVal, 0).fLR()
-		v = v.slice(L, R).copy(exprVal.slice(0, 5)).apply(v)
+		L, R := fVal/8, fVal%8
+		v = a.b.slice(v, L, R).copy(a.b.slice(exprVal, 0, 5)).apply(v)
 		startExpr = endF + 1
 	}
 	return v, nil
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -138,3 +140,96 @@ func TestWValue(t *testing.T) {
 		}
 	}
 }
+
+func TestAssembleInst(t *testing.T) {
+	tests := []struct {
+		Op, Operand string
+		Want        Word
+		Err         error
+	}{
+		{"LDA", "4095", composeInst(4095, 0, 5, C_LD), nil},
+		{"LDA", "-4095,6(0:7)", -composeInst(4095, 6, 7, C_LD), nil},
+		{"IN", "1000(63)", composeInst(1000, 0, 63, C_IO+2), nil},
+		{"LDA", "5000", 0, ErrAddressRange},
+		{"LDA", "-4096", 0, ErrAddressRange},
+		{"LDA", "1000,7", 0, ErrIndexRange},
+		{"LDA", "1000,70", 0, ErrIndexRange},
+		{"LDA", "1000(9:9)", 0, ErrFieldRange},
+		{"IN", "1000(99)", 0, ErrFieldRange},
+	}
+	for _, test := range tests {
+		v, err := a.inst(test.Op, test.Operand)
+		if v != test.Want || !errors.Is(err, test.Err) {
+			t.Errorf("%s %s: want %v, got %v%s", test.Op, test.Operand, test.Err, err, wordDiff(test.Want, v))
+		}
+	}
+}
+
+func TestAssemble(t *testing.T) {
+	src := `* labels, future references and default fields
+X        EQU  1000
+         ORIG 3000
+START    STJ  EXIT
+         LDA  X,1(1:3)
+         ENT1 -5
+         DEC2 1
+         JMP  EXIT
+EXIT     JMP  *
+         END  START
+`
+	m := NewMachine()
+	start, err := NewAssembler().Assemble(m, strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start != 3000 {
+		t.Errorf("want start 3000, got %d", start)
+	}
+	want := []Word{
+		composeInst(3005, 0, 2, 32),              // STJ EXIT
+		composeInst(1000, 1, 1*8+3, C_LD),        // LDA X,1(1:3)
+		-composeInst(5, 0, 2, C_ADDR_TRANSFER+1), // ENT1 -5
+		composeInst(1, 0, 1, C_ADDR_TRANSFER+2),  // DEC2 1
+		composeInst(3005, 0, 0, C_JMP),           // JMP EXIT
+		composeInst(3005, 0, 0, C_JMP),           // JMP *
+	}
+	for i, w := range want {
+		if got := m.Mem[3000+i]; got != w {
+			t.Errorf("%d: %s", 3000+i, wordDiff(w, got))
+		}
+	}
+	if m.Mem[3006] != 0 {
+		t.Errorf("want END to leave memory alone, got %v at 3006", m.Mem[3006])
+	}
+
+	m = NewMachine(WithMemorySize(100))
+	if _, err := NewAssembler().Assemble(m, strings.NewReader(src)); !errors.Is(err, ErrLocation) {
+		t.Errorf("want %v, got %v", ErrLocation, err)
+	}
+
+	literals := `         ORIG 3000
+START    LDA  =5=
+         ADD  =1(1:1)=,1
+         HLT
+`
+	m = NewMachine()
+	if _, err := NewAssembler().Assemble(m, strings.NewReader(literals)); !errors.Is(err, ErrNoEnd) {
+		t.Errorf("want %v, got %v", ErrNoEnd, err)
+	}
+	m = NewMachine()
+	if _, err := NewAssembler().Assemble(m, strings.NewReader(literals+"         END  START\n")); err != nil {
+		t.Fatal(err)
+	}
+	want = []Word{
+		composeInst(3003, 0, 5, C_LD),  // LDA =5=
+		composeInst(3004, 1, 5, C_ADD), // ADD =1(1:1)=,1
+		composeInst(0, 0, 2, C_SPECIAL),
+		5,
+		composeWord(1, 0, 0, 0, 0),
+	}
+	for i, w := range want {
+		if got := m.Mem[3000+i]; got != w {
+			t.Errorf("literals %d: %s", 3000+i, wordDiff(w, got))
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Op describes a MIX instruction. Ops is the one list of them: Exec,
+// the assembler and the disassembler all look instructions up there.
+type Op struct {
+	Name  string
+	C     Word  // opcode
+	F     Word  // the F selecting the instruction, or its default F
+	FKind FKind // what F is
+	Time  Word  // in u, see duration
+	Uses  Use
+	Needs Feature
+	exec  func(m *Arch, inst, M Word) error // nil for NOP
+}
+
+// FKind is what the F of an instruction is.
+type FKind int
+
+const (
+	FSelect FKind = iota // part of the opcode, F must be Op.F
+	FField               // a field (L:R), 0 <= L <= R <= 5
+	FUnit                // an attached I/O unit
+	FCount               // the number of words to MOVE, any byte
+	FAny                 // unused, any byte
+)
+
+// Use is what an instruction works on. Memory is the cells at M.
+type Use int
+
+const (
+	ReadsMemory Use = 1 << iota
+	WritesMemory
+	ReadsRegisters
+	WritesRegisters
+	UsesDevice // unit F
+	Jumps      // to M, so M has to be a location too
+)
+
+var useNames = []string{"reads memory", "writes memory", "reads registers", "writes registers", "uses device", "jumps"}
+
+func (u Use) String() string {
+	var names []string
+	for i, name := range useNames {
+		if u&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// location reports whether the instruction uses M as a memory location,
+// as opposed to a value (address transfers, shifts) or nothing at all.
+func (u Use) location() bool {
+	return u&(ReadsMemory|WritesMemory|Jumps) != 0
+}
+
+// Feature is an attachment or extension an instruction needs.
+type Feature int
+
+const (
+	Standard Feature = iota
+	FloatingPoint
+	BinaryExtensions
+	InterruptFacility
+)
+
+// do and just adapt handlers to the exec of an Op.
+
+func do(h func(m *Arch, inst, M Word)) func(m *Arch, inst, M Word) error {
+	return func(m *Arch, inst, M Word) error {
+		h(m, inst, M)
+		return nil
+	}
+}
+
+func just(h func(m *Arch)) func(m *Arch, inst, M Word) error {
+	return func(m *Arch, inst, M Word) error {
+		h(m)
+		return nil
+	}
+}
+
+const (
+	rm, wm = ReadsMemory, WritesMemory
+	rr, wr = ReadsRegisters, WritesRegisters
+)
+
+// Ops lists the instructions of TAOCP 1.3.1, the floating point
+// attachment of 4.2.1, the binary extensions and the interrupt facility.
+// A * in a name stands for each of the registers A, 1-6 and X,
+// whose opcodes follow C in that order. Times are Knuth's.
+var Ops = expand([]Op{
+	{Name: "NOP", C: 0, FKind: FAny, Time: 1},
+	{Name: "ADD", C: C_ADD, F: 5, FKind: FField, Time: 2, Uses: rm | rr | wr, exec: do((*Arch).Add)},
+	{Name: "SUB", C: C_SUB, F: 5, FKind: FField, Time: 2, Uses: rm | rr | wr, exec: do((*Arch).Add)},
+	{Name: "MUL", C: C_MUL, F: 5, FKind: FField, Time: 10, Uses: rm | rr | wr, exec: do((*Arch).Mul)},
+	{Name: "DIV", C: C_DIV, F: 5, FKind: FField, Time: 12, Uses: rm | rr | wr, exec: (*Arch).Div},
+	{Name: "FADD", C: C_ADD, F: 6, Time: 4, Uses: rm | rr | wr, Needs: FloatingPoint, exec: do((*Arch).Float)},
+	{Name: "FSUB", C: C_SUB, F: 6, Time: 4, Uses: rm | rr | wr, Needs: FloatingPoint, exec: do((*Arch).Float)},
+	{Name: "FMUL", C: C_MUL, F: 6, Time: 9, Uses: rm | rr | wr, Needs: FloatingPoint, exec: do((*Arch).Float)},
+	{Name: "FDIV", C: C_DIV, F: 6, Time: 11, Uses: rm | rr | wr, Needs: FloatingPoint, exec: do((*Arch).Float)},
+
+	{Name: "NUM", C: C_SPECIAL, F: 0, Time: 10, Uses: rr | wr, exec: just((*Arch).Num)},
+	{Name: "CHAR", C: C_SPECIAL, F: 1, Time: 10, Uses: rr | wr, exec: just((*Arch).Char)},
+	{Name: "HLT", C: C_SPECIAL, F: 2, Time: 10, exec: just(func(m *Arch) { m.State = Halted })},
+	{Name: "FLOT", C: C_SPECIAL, F: 6, Time: 3, Uses: rr | wr, Needs: FloatingPoint, exec: do((*Arch).Float)},
+	{Name: "FIX", C: C_SPECIAL, F: 7, Time: 3, Uses: rr | wr, Needs: FloatingPoint, exec: do((*Arch).Float)},
+	{Name: "INT", C: C_SPECIAL, F: 9, Time: 2, Uses: rr | wr, Needs: InterruptFacility, exec: just((*Arch).Int)},
+	{Name: "AND", C: C_SPECIAL, F: 10, Time: 2, Uses: rm | rr | wr, Needs: BinaryExtensions, exec: do((*Arch).Bitwise)},
+	{Name: "OR", C: C_SPECIAL, F: 11, Time: 2, Uses: rm | rr | wr, Needs: BinaryExtensions, exec: do((*Arch).Bitwise)},
+	{Name: "XOR", C: C_SPECIAL, F: 12, Time: 2, Uses: rm | rr | wr, Needs: BinaryExtensions, exec: do((*Arch).Bitwise)},
+
+	{Name: "SLA", C: C_SHIFT, F: 0, Time: 2, Uses: rr | wr, exec: (*Arch).Shift},
+	{Name: "SRA", C: C_SHIFT, F: 1, Time: 2, Uses: rr | wr, exec: (*Arch).Shift},
+	{Name: "SLAX", C: C_SHIFT, F: 2, Time: 2, Uses: rr | wr, exec: (*Arch).Shift},
+	{Name: "SRAX", C: C_SHIFT, F: 3, Time: 2, Uses: rr | wr, exec: (*Arch).Shift},
+	{Name: "SLC", C: C_SHIFT, F: 4, Time: 2, Uses: rr | wr, exec: (*Arch).Shift},
+	{Name: "SRC", C: C_SHIFT, F: 5, Time: 2, Uses: rr | wr, exec: (*Arch).Shift},
+	{Name: "SLB", C: C_SHIFT, F: 6, Time: 2, Uses: rr | wr, Needs: BinaryExtensions, exec: (*Arch).Shift},
+	{Name: "SRB", C: C_SHIFT, F: 7, Time: 2, Uses: rr | wr, Needs: BinaryExtensions, exec: (*Arch).Shift},
+	{Name: "MOVE", C: C_MOVE, F: 1, FKind: FCount, Time: 1, Uses: rm | wm | rr | wr, exec: (*Arch).Move},
+
+	{Name: "LD*", C: C_LD, F: 5, FKind: FField, Time: 2, Uses: rm | wr, exec: (*Arch).Load},
+	{Name: "LD*N", C: C_LDN, F: 5, FKind: FField, Time: 2, Uses: rm | wr, exec: (*Arch).Load},
+	{Name: "ST*", C: C_ST, F: 5, FKind: FField, Time: 2, Uses: wm | rr, exec: do((*Arch).Store)},
+	{Name: "STJ", C: C_ST + J, F: 2, FKind: FField, Time: 2, Uses: wm | rr, exec: do((*Arch).Store)},
+	{Name: "STZ", C: C_ST + J + 1, F: 5, FKind: FField, Time: 2, Uses: wm, exec: do((*Arch).Store)},
+
+	{Name: "JBUS", C: C_IO, FKind: FUnit, Time: 1, Uses: UsesDevice | Jumps | wr, exec: (*Arch).IO},
+	{Name: "IOC", C: C_IO + 1, FKind: FUnit, Time: 1, Uses: UsesDevice | rr, exec: (*Arch).IO},
+	{Name: "IN", C: C_IO + 2, FKind: FUnit, Time: 1, Uses: UsesDevice | wm | rr, exec: (*Arch).IO},
+	{Name: "OUT", C: C_IO + 3, FKind: FUnit, Time: 1, Uses: UsesDevice | rm | rr, exec: (*Arch).IO},
+	{Name: "JRED", C: C_IO + 4, FKind: FUnit, Time: 1, Uses: UsesDevice | Jumps | wr, exec: (*Arch).IO},
+
+	{Name: "JMP", C: C_JMP, F: 0, Time: 1, Uses: Jumps | wr, exec: do((*Arch).Jump)},
+	{Name: "JSJ", C: C_JMP, F: 1, Time: 1, Uses: Jumps, exec: do((*Arch).Jump)},
+	{Name: "JOV", C: C_JMP, F: 2, Time: 1, Uses: Jumps | wr, exec: do((*Arch).Jump)},
+	{Name: "JNOV", C: C_JMP, F: 3, Time: 1, Uses: Jumps | wr, exec: do((*Arch).Jump)},
+	{Name: "JL", C: C_JMP, F: 4, Time: 1, Uses: Jumps | wr, exec: do((*Arch).Jump)},
+	{Name: "JE", C: C_JMP, F: 5, Time: 1, Uses: Jumps | wr, exec: do((*Arch).Jump)},
+	{Name: "JG", C: C_JMP, F: 6, Time: 1, Uses: Jumps | wr, exec: do((*Arch).Jump)},
+	{Name: "JGE", C: C_JMP, F: 7, Time: 1, Uses: Jumps | wr, exec: do((*Arch).Jump)},
+	{Name: "JNE", C: C_JMP, F: 8, Time: 1, Uses: Jumps | wr, exec: do((*Arch).Jump)},
+	{Name: "JLE", C: C_JMP, F: 9, Time: 1, Uses: Jumps | wr, exec: do((*Arch).Jump)},
+	{Name: "J*N", C: C_JREG, F: 0, Time: 1, Uses: Jumps | rr | wr, exec: do((*Arch).Jump)},
+	{Name: "J*Z", C: C_JREG, F: 1, Time: 1, Uses: Jumps | rr | wr, exec: do((*Arch).Jump)},
+	{Name: "J*P", C: C_JREG, F: 2, Time: 1, Uses: Jumps | rr | wr, exec: do((*Arch).Jump)},
+	{Name: "J*NN", C: C_JREG, F: 3, Time: 1, Uses: Jumps | rr | wr, exec: do((*Arch).Jump)},
+	{Name: "J*NZ", C: C_JREG, F: 4, Time: 1, Uses: Jumps | rr | wr, exec: do((*Arch).Jump)},
+	{Name: "J*NP", C: C_JREG, F: 5, Time: 1, Uses: Jumps | rr | wr, exec: do((*Arch).Jump)},
+	{Name: "JAE", C: C_JREG + A, F: 6, Time: 1, Uses: Jumps | rr | wr, Needs: BinaryExtensions, exec: do((*Arch).Jump)},
+	{Name: "JAO", C: C_JREG + A, F: 7, Time: 1, Uses: Jumps | rr | wr, Needs: BinaryExtensions, exec: do((*Arch).Jump)},
+	{Name: "JXE", C: C_JREG + X, F: 6, Time: 1, Uses: Jumps | rr | wr, Needs: BinaryExtensions, exec: do((*Arch).Jump)},
+	{Name: "JXO", C: C_JREG + X, F: 7, Time: 1, Uses: Jumps | rr | wr, Needs: BinaryExtensions, exec: do((*Arch).Jump)},
+
+	{Name: "INC*", C: C_ADDR_TRANSFER, F: 0, Time: 1, Uses: rr | wr, exec: (*Arch).AddressTransfer},
+	{Name: "DEC*", C: C_ADDR_TRANSFER, F: 1, Time: 1, Uses: rr | wr, exec: (*Arch).AddressTransfer},
+	{Name: "ENT*", C: C_ADDR_TRANSFER, F: 2, Time: 1, Uses: wr, exec: (*Arch).AddressTransfer},
+	{Name: "ENN*", C: C_ADDR_TRANSFER, F: 3, Time: 1, Uses: wr, exec: (*Arch).AddressTransfer},
+
+	{Name: "CMP*", C: C_CMP, F: 5, FKind: FField, Time: 2, Uses: rm | rr, exec: do((*Arch).Compare)},
+	{Name: "FCMP", C: C_CMP, F: 6, Time: 4, Uses: rm | rr, Needs: FloatingPoint, exec: do((*Arch).Float)},
+})
+
+// expand gives each register its own Op for the names with a *.
+func expand(table []Op) []Op {
+	var ops []Op
+	for _, op := range table {
+		if !strings.Contains(op.Name, "*") {
+			ops = append(ops, op)
+			continue
+		}
+		for rI, r := range "A123456X" {
+			reg := op
+			reg.Name, reg.C = strings.Replace(op.Name, "*", string(r), 1), op.C+Word(rI)
+			ops = append(ops, reg)
+		}
+	}
+	return ops
+}
+
+// opsByC and opsByName index Ops.
+var opsByC, opsByName = func() (map[Word][]*Op, map[string]*Op) {
+	byC, byName := make(map[Word][]*Op), make(map[string]*Op)
+	for i := range Ops {
+		op := &Ops[i]
+		byC[op.C] = append(byC[op.C], op)
+		byName[op.Name] = op
+	}
+	return byC, byName
+}()
+
+// lookupOp returns the instruction with opcode c and field F,
+// and whether any instruction has opcode c.
+func lookupOp(c, F Word) (op *Op, known bool) {
+	for _, o := range opsByC[c] {
+		switch {
+		case o.FKind == FSelect && o.F == F:
+			return o, true
+		case o.FKind != FSelect:
+			op = o
+		}
+	}
+	return op, 0 < len(opsByC[c])
+}
+
+// duration returns the time in units u the instruction takes with F,
+// MOVE taking 2u more for each word. I/O instructions are charged
+// 1u here, waiting for a busy unit is charged separately, see errWaited.
+func (op *Op) duration(F Word) Word {
+	if op.FKind == FCount {
+		return op.Time + 2*F
+	}
+	return op.Time
+}
+
+// WriteOpcodes writes Ops as a chart of the instruction set.
+func WriteOpcodes(w io.Writer) error {
+	kinds := [...]string{FSelect: "", FField: "field", FUnit: "unit", FCount: "count", FAny: "any"}
+	needs := [...]string{Standard: "", FloatingPoint: "floating point", BinaryExtensions: "binary", InterruptFacility: "interrupts"}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tC\tF\tTIME\tUSES\tNEEDS")
+	for _, op := range Ops {
+		F := fmt.Sprint(op.F, " ", kinds[op.FKind])
+		time := fmt.Sprintf("%du", op.Time)
+		if op.FKind == FCount {
+			time = fmt.Sprintf("%d+2Fu", op.Time)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%v\t%s\n", op.Name, op.C, strings.TrimSpace(F), time, op.Uses, needs[op.Needs])
+	}
+	return tw.Flush()
+}
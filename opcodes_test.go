@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestOps checks that each instruction in Ops is looked up, assembled
+// and disassembled as itself.
+func TestOps(t *testing.T) {
+	var src strings.Builder
+	for _, op := range Ops {
+		src.WriteString(" " + op.Name + " 1000\n")
+	}
+	m := NewMachine()
+	if _, err := NewAssembler().Assemble(m, strings.NewReader(src.String())); err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]bool)
+	for i := range Ops {
+		op := &Ops[i]
+		if names[op.Name] {
+			t.Errorf("%s listed twice", op.Name)
+		}
+		names[op.Name] = true
+		inst := composeInst(1000, 0, op.F, op.C)
+		if got, _ := lookupOp(op.C, op.F); got != op {
+			t.Errorf("%s: looked up %+v", op.Name, got)
+		}
+		if got := m.Mem[i]; got != inst {
+			t.Errorf("%s: assembled %s", op.Name, wordDiff(inst, got))
+		}
+		if want, got := op.Name+" 1000", Binary.disassemble(inst); got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	}
+}
+
+func TestWriteOpcodes(t *testing.T) {
+	var chart bytes.Buffer
+	if err := WriteOpcodes(&chart); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(chart.String()), "\n")
+	if len(lines) != 1+len(Ops) {
+		t.Fatalf("want %d lines, got %d", 1+len(Ops), len(lines))
+	}
+	for _, want := range [][]string{
+		{"MOVE", "7", "1 count", "1+2Fu", "reads memory, writes memory, reads registers, writes registers"},
+		{"FADD", "1", "6", "4u", "floating point"},
+	} {
+		found := false
+		for _, line := range lines {
+			if fields := strings.Fields(line); 0 < len(fields) && fields[0] == want[0] {
+				found = true
+				for _, w := range want[1:] {
+					if !strings.Contains(line, w) {
+						t.Errorf("%s: want %q in %q", want[0], w, line)
+					}
+				}
+			}
+		}
+		if !found {
+			t.Errorf("no %s in chart", want[0])
+		}
+	}
+}
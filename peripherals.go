@@ -1,10 +1,15 @@
 package main
 
-type MIXDevice []Word
-type MIXDevices []MIXDevice
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
 
+// Unit numbers of the MIX peripherals, the F field of I/O instructions.
 const (
-	TAPE0 = iota // 100 words per tape
+	TAPE0 = iota // 100 words per block
 	TAPE1
 	TAPE2
 	TAPE3
@@ -12,7 +17,7 @@ const (
 	TAPE5
 	TAPE6
 	TAPE7
-	DISK0 // 100 words per tape
+	DISK0 // 100 words per block
 	DISK1
 	DISK2
 	DISK3
@@ -25,29 +30,167 @@ const (
 	LINE_PRINTER // 24 words
 	TERMINAL     // 14 words
 	PAPER_TAPE   // 14 words
+	UNITS
 )
 
-// Need:
-// character code mapping (could just map sections of ascii to MIX chars)
-// devices also have position / last written index
-// model device operations with "parallel" work relative to machine (repr time with counter)
-// readiness
-func peripherals() MIXDevices {
-	newDevice := func(blockSize int) MIXDevice {
-		return make(MIXDevice, blockSize)
-	}
-
-	devices := make(MIXDevices, 20)
-	for i := TAPE0; i <= TAPE7; i++ {
-		devices[i] = newDevice(100)
-	}
-	for i := DISK0; i <= DISK7; i++ {
-		devices[i] = newDevice(100)
-	}
-	devices[CARD_READER] = newDevice(16)
-	devices[CARD_PUNCHER] = newDevice(16)
-	devices[LINE_PRINTER] = newDevice(24)
-	devices[TERMINAL] = newDevice(14)
-	devices[PAPER_TAPE] = newDevice(14)
-	return devices
+// Device is a peripheral attached to a unit with WithDevice.
+// x is the contents of rX, which disks use as the block number.
+type Device interface {
+	BlockSize() Word
+	In(block []Word, x Word) error  // IN, fill block
+	Out(block []Word, x Word) error // OUT, take block
+	Control(M, x Word) error        // IOC
+}
+
+// unit is a device attached to a machine. It is busy from
+// the start of an operation until the machine time reaches ready.
+type unit struct {
+	dev            Device
+	latency, ready Word
+	notify         bool // interrupt when ready
+}
+
+var ErrDevice = errors.New("device: operation failed")
+
+// attacher is a device that depends on the machine it is attached to.
+// NewMachine calls attach once all options are applied.
+type attacher interface {
+	attach(m *Arch)
+}
+
+// StorageBlocks is how many blocks a tape or disk holds.
+const StorageBlocks = 4096
+
+// Storage is a tape or disk, blocks of 100 words kept in memory.
+// A tape reads and writes at its position, moved by IOC M: M blocks
+// forward or back, or to the start for M = 0. A disk reads and writes
+// the block in rX. Blocks past StorageBlocks fail with ErrDevice.
+type Storage struct {
+	Blocks [][]Word
+	pos    Word
+	disk   bool
+}
+
+func NewTape() *Storage { return &Storage{} }
+func NewDisk() *Storage { return &Storage{disk: true} }
+
+func (s *Storage) BlockSize() Word { return 100 }
+
+// block returns the block to read or write, nil if there is none.
+func (s *Storage) block(x Word) []Word {
+	i := s.pos
+	if s.disk {
+		i = x
+	}
+	if i < 0 || StorageBlocks <= i {
+		return nil
+	}
+	for Word(len(s.Blocks)) <= i {
+		s.Blocks = append(s.Blocks, make([]Word, s.BlockSize()))
+	}
+	if !s.disk {
+		s.pos++
+	}
+	return s.Blocks[i]
+}
+
+func (s *Storage) In(block []Word, x Word) error {
+	b := s.block(x)
+	if b == nil {
+		return ErrDevice
+	}
+	copy(block, b)
+	return nil
+}
+
+func (s *Storage) Out(block []Word, x Word) error {
+	b := s.block(x)
+	if b == nil {
+		return ErrDevice
+	}
+	copy(b, block)
+	return nil
+}
+
+func (s *Storage) Control(M, x Word) error {
+	switch {
+	case s.disk:
+	case M == 0:
+		s.pos = 0
+	case s.pos+M < 0:
+		s.pos = 0
+	default:
+		s.pos += M
+	}
+	return nil
+}
+
+// Text is a character device, such as the card reader or line printer,
+// moving one line of MIX characters per block. IN reads a line from r,
+// OUT writes one to w without its trailing spaces.
+type Text struct {
+	b    ByteSize
+	size Word
+	r    *bufio.Reader
+	w    io.Writer
+}
+
+// NewText makes a character device with blocks of size words.
+// r or w is nil for a device that only writes or reads. Characters
+// are coded in the byte size of the machine it is attached to.
+func NewText(size Word, r io.Reader, w io.Writer) *Text {
+	t := &Text{size: size, w: w}
+	if r != nil {
+		t.r = bufio.NewReader(r)
+	}
+	return t
+}
+
+func (t *Text) attach(m *Arch) { t.b = m.b }
+
+func (t *Text) BlockSize() Word { return t.size }
+
+func (t *Text) In(block []Word, x Word) error {
+	if t.r == nil {
+		return ErrDevice
+	}
+	line, err := t.r.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return err
+	}
+	chars := []rune(strings.TrimRight(line, "\r\n"))
+	for i := range block {
+		var s string
+		if start := i * WORDSIZE; start < len(chars) {
+			end := start + WORDSIZE
+			if len(chars) < end {
+				end = len(chars)
+			}
+			s = string(chars[start:end])
+		}
+		if block[i], err = t.b.encodeWord(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Text) Out(block []Word, x Word) error {
+	if t.w == nil {
+		return ErrDevice
+	}
+	var line strings.Builder
+	for _, w := range block {
+		s, err := t.b.decodeWord(w)
+		if err != nil {
+			return err
+		}
+		line.WriteString(s)
+	}
+	_, err := io.WriteString(t.w, strings.TrimRight(line.String(), " ")+"\n")
+	return err
+}
+
+func (t *Text) Control(M, x Word) error {
+	return nil
 }
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestIO(t *testing.T) {
+	var out bytes.Buffer
+	m := NewMachine(
+		WithDevice(CARD_READER, NewText(16, strings.NewReader("HELLO WORLD\n"), nil), 100),
+		WithDevice(LINE_PRINTER, NewText(24, nil, &out), 50),
+	)
+	copy(m.Mem, []Word{
+		composeInst(1000, 0, CARD_READER, C_IO+2),  // IN 1000(16)
+		composeInst(1, 0, CARD_READER, C_IO),       // JBUS *(16)
+		composeInst(1000, 0, LINE_PRINTER, C_IO+3), // OUT 1000(18)
+		composeInst(0, 0, 2, C_SPECIAL),            // HLT
+	})
+	if err := m.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "HELLO WORLD\n" {
+		t.Errorf("want HELLO WORLD printed, got %q", out.String())
+	}
+	// IN, JBUS until the reader is ready at 101, OUT and HLT
+	if want := (Summary{Time: 1 + 101 + 1 + 10, Instructions: 104}); m.Summary() != want {
+		t.Errorf("want %v, got %v", want, m.Summary())
+	}
+
+	m.Time = 200
+	for i := 0; i < 2; i++ {
+		if err := m.Exec(composeInst(1000, 0, LINE_PRINTER, C_IO+3)); err != nil { // OUT 1000(18)
+			t.Fatal(err)
+		}
+	}
+	if m.Time != 252 { // the second OUT waits for the first
+		t.Errorf("want time 252, got %v", m.Time)
+	}
+	if err := m.Exec(composeInst(1000, 0, CARD_READER, C_IO+2)); !errors.Is(err, ErrDevice) { // IN 1000(16)
+		t.Errorf("want %v at end of cards, got %v", ErrDevice, err)
+	}
+}
+
+func TestWaitingIO(t *testing.T) {
+	m := NewMachine(WithDevice(LINE_PRINTER, NewText(24, nil, &bytes.Buffer{}), 50))
+	copy(m.Mem, []Word{
+		composeInst(1000, 0, LINE_PRINTER, C_IO+3), // OUT 1000(18)
+		composeInst(1000, 0, LINE_PRINTER, C_IO+3), // OUT 1000(18)
+	})
+	tests := []struct {
+		state          State
+		pc, time, runs Word
+	}{
+		{Running, 1, 1, 1},
+		{WaitingIO, 1, 51, 1}, // the printer is ready at 51
+		{Running, 2, 52, 2},
+	}
+	for i, test := range tests {
+		if err := m.Step(); err != nil {
+			t.Fatal(err)
+		}
+		if m.State != test.state || m.PC != test.pc || m.Time != test.time || m.Executed != test.runs {
+			t.Errorf("step %d: want %v at %d, time %d after %d, got %v at %d, time %d after %d", i,
+				test.state, test.pc, test.time, test.runs, m.State, m.PC, m.Time, m.Executed)
+		}
+	}
+}
+
+func TestStorage(t *testing.T) {
+	tape, disk := NewTape(), NewDisk()
+	m := NewMachine(WithDevice(TAPE0, tape, 10), WithDevice(DISK0, disk, 10))
+	for i := Word(0); i < 100; i++ {
+		m.Write(2000+i, i)
+	}
+	m.R[X].w = 3
+	for _, inst := range []Word{
+		composeInst(2000, 0, TAPE0, C_IO+3), // OUT 2000(0)
+		composeInst(2000, 0, TAPE0, C_IO+3), // OUT 2000(0)
+		composeInst(0, 0, TAPE0, C_IO+1),    // IOC 0(0)
+		composeInst(1, 0, TAPE0, C_IO+1),    // IOC 1(0)
+		composeInst(3000, 0, TAPE0, C_IO+2), // IN 3000(0)
+		composeInst(2000, 0, DISK0, C_IO+3), // OUT 2000(8)
+		composeInst(3100, 0, DISK0, C_IO+2), // IN 3100(8)
+	} {
+		if err := m.Exec(inst); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(tape.Blocks) != 2 || len(disk.Blocks) != 4 {
+		t.Errorf("want 2 tape and 4 disk blocks, got %v and %v", len(tape.Blocks), len(disk.Blocks))
+	}
+	for i := Word(0); i < 100; i++ {
+		if m.Read(3000+i) != i || m.Read(3100+i) != i {
+			t.Fatalf("block word %v: want %v, got %v and %v", i, i, m.Read(3000+i), m.Read(3100+i))
+		}
+	}
+	for _, x := range []Word{-1, StorageBlocks} {
+		m.R[X].w = x
+		if err := m.Exec(composeInst(2000, 0, DISK0, C_IO+3)); !errors.Is(err, ErrDevice) { // OUT 2000(8)
+			t.Errorf("block %v: want %v, got %v", x, ErrDevice, err)
+		}
+	}
+}
+
+// TestTextByteSize tests a character device codes characters
+// in the byte size of its machine, however the options are ordered.
+func TestTextByteSize(t *testing.T) {
+	var out bytes.Buffer
+	m := NewMachine(WithDevice(TERMINAL, NewText(14, nil, &out), 0), WithByteSize(Decimal))
+	w, err := Decimal.encodeWord("HELLO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Write(1000, w)
+	if err := m.Exec(Decimal.inst(1000, 0, TERMINAL, C_IO+3)); err != nil { // OUT 1000(19)
+		t.Fatal(err)
+	}
+	if out.String() != "HELLO\n" {
+		t.Errorf("want HELLO, got %q", out.String())
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Count is how many times the instruction at a location ran
+// and the time it took altogether, in u.
+type Count struct {
+	Runs, Time Word
+}
+
+// ProfileEntry is the Count of a location, with its MIXAL source.
+type ProfileEntry struct {
+	Loc Word
+	Count
+	Source string
+}
+
+// WithProfile counts the runs and time of the instruction at each location.
+func WithProfile() Option {
+	return func(m *Arch) { m.profile = make(map[Word]Count) }
+}
+
+// Profile returns the counted locations, those that used the most time
+// first. With a, the Assembler the program came from, each entry has
+// its source line.
+func (m *Arch) Profile(a *Assembler) []ProfileEntry {
+	m.mu.Lock()
+	entries := make([]ProfileEntry, 0, len(m.profile))
+	for loc, c := range m.profile {
+		entries = append(entries, ProfileEntry{Loc: loc, Count: c})
+	}
+	m.mu.Unlock()
+	if a != nil {
+		source := make(map[Word]string)
+		for _, l := range a.Lines {
+			if 0 <= l.Loc {
+				source[l.Loc] = l.Text
+			}
+		}
+		for i := range entries {
+			entries[i].Source = source[entries[i].Loc]
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Time != entries[j].Time {
+			return entries[i].Time > entries[j].Time
+		}
+		return entries[i].Loc < entries[j].Loc
+	})
+	return entries
+}
+
+// WriteListing writes the source assembled by a with the runs and time
+// of each instruction in the margin, like the counts beside Knuth's programs.
+func (m *Arch) WriteListing(w io.Writer, a *Assembler) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, l := range a.Lines {
+		c, ran := m.profile[l.Loc]
+		margin := fmt.Sprintf("%4s %8s %8s", "", "", "")
+		if 0 <= l.Loc && (l.Inst || ran) {
+			margin = fmt.Sprintf("%4d %8d %8d", l.Loc, c.Runs, c.Time)
+		}
+		if _, err := fmt.Fprintf(w, "%s | %s\n", margin, l.Text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// programM is Program M of TAOCP 1.3.2, with a driver.
+const programM = `* MAXIMUM OF X[1..N]
+X        EQU  1000
+         ORIG 3000
+MAXIMUM  STJ  EXIT       Subroutine linkage
+INIT     ENT3 0,1        M1. Initialize. k <- n.
+         JMP  CHANGEM    j <- n, m <- X[n], k <- n-1.
+LOOP     CMPA X,3        M3. Compare.
+         JGE  *+3        To M5 if m >= X[k].
+CHANGEM  ENT2 0,3        M4. Change m. j <- k.
+         LDA  X,3        m <- X[k].
+         DEC3 1          M5. Decrease k.
+         J3P  LOOP       M2. All tested? To M3 if k > 0.
+EXIT     JMP  *          Return to main program.
+START    ENT1 5
+         JMP  MAXIMUM
+         HLT
+         END  START
+`
+
+func TestProfile(t *testing.T) {
+	m := NewMachine(WithProfile())
+	a := NewAssembler()
+	start, err := a.Assemble(m, strings.NewReader(programM))
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(m.Mem[1001:], []Word{5, 1, 4, 1, 3}) // n = 5, A = 2
+	m.PC = start
+	if err := m.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if m.R[A].w != 5 || m.R[I2].w != 1 {
+		t.Errorf("want max 5 at 1, got %v at %v", m.R[A].w, m.R[I2].w)
+	}
+
+	n, A := Word(5), Word(2)
+	runs := []Word{1, 1, 1, n - 1, n - 1, A + 1, A + 1, n, n, 1, 1, 1, 1}
+	profile := m.Profile(a)
+	if len(profile) != len(runs) {
+		t.Fatalf("want %d locations, got %d", len(runs), len(profile))
+	}
+	for _, e := range profile {
+		if want := runs[e.Loc-3000]; e.Runs != want {
+			t.Errorf("%d %q: want %d runs, got %d", e.Loc, e.Source, want, e.Runs)
+		}
+	}
+	for i := 1; i < len(profile); i++ {
+		if profile[i-1].Time < profile[i].Time {
+			t.Errorf("not by time: %+v before %+v", profile[i-1], profile[i])
+		}
+	}
+	// HLT takes 10u, then the compare in the loop
+	if e := profile[1]; e.Loc != 3003 || e.Time != 2*(n-1) || !strings.HasPrefix(e.Source, "LOOP") {
+		t.Errorf("want CMPA at 3003 second, got %+v", e)
+	}
+
+	var listing bytes.Buffer
+	if err := m.WriteListing(&listing, a); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(listing.String(), "\n")
+	if want := "3003        4        8 | LOOP     CMPA X,3        M3. Compare."; lines[6] != want {
+		t.Errorf("want %q, got %q", want, lines[6])
+	}
+	if want := "                       | X        EQU  1000"; lines[1] != want {
+		t.Errorf("want %q, got %q", want, lines[1])
+	}
+}
@@ -1,76 +1,141 @@
 package main
 
-/*
-import (
-	"bufio"
-	"io"
-)
+import "errors"
 
+// History keeps, for each of the last steps, what the step changed:
+// the registers, PC, clock and indicators from before it, the old value
+// of each memory cell it wrote and how to undo its device operations.
+// Stepping back puts these back. Text read or written by character
+// devices can't be taken back, only the machine side of it is undone.
 
-	Keep track of state with each "tick"
-	either as a diff b/n 2 consecutive states (less mem, would need to compute)
-	or just a snapshot (more mem, just save)
+var ErrNoHistory = errors.New("history: no step to go back to")
 
+// effect is what one step changed, holding the values from before it.
+// History and traces are made from it.
+type effect struct {
+	at, inst, M          Word // location, instruction and its address
+	R                    [J + 1]Word
+	PC, Time, Executed   Word
+	State                State
+	OverflowToggle       bool
+	Less, Equal, Greater bool
+	Control, pending     bool
+	cells                []cell // in the order written
+	units                []unitState
+	undo                 []func() // device operations, in the order done
+}
+
+type cell struct {
+	address, old Word
+}
+
+type unitState struct {
+	u      *unit
+	ready  Word
+	notify bool
+}
 
-// Keeps track of registers, memory, and devices
-// affected by an instruction.
-// (indices for R and Mem, and unit # for devices)
-// data is interpreted as a stream containing
-// changed register, memory, and device data in
-// that order.
-type Snapshot struct {
-	RI, MemI, DeviceI []int
-	Data              Word
+// WithHistory keeps what the last steps changed, so StepBack can undo them.
+func WithHistory(steps int) Option {
+	return func(m *Arch) { m.historySize = steps }
 }
 
-// Maybe a better approach would be to pair a label
-// with each index so it wouldn't matter what order they're added.
-// PROFILE: How much overhead due to defer?
-func (s *Snapshot) includesR(i int, data Register) {
-	s.RI = append(s.RI, i)
-	s.Data = append(s.Data, data...)
+// record starts the effect of the step about to run.
+func (m *Arch) record() {
+	e := &effect{
+		PC: m.PC, Time: m.Time, Executed: m.Executed, State: m.State,
+		OverflowToggle: m.OverflowToggle, Control: m.Control, pending: m.pending,
+	}
+	for i := range e.R {
+		e.R[i] = m.R[i].w
+	}
+	e.Less, e.Equal, e.Greater = m.Comparisons()
+	for _, u := range m.units {
+		e.units = append(e.units, unitState{u, u.ready, u.notify})
+	}
+	m.effect = e
 }
 
-func (s *Snapshot) includesCell(i int, data MIXBytes) {
-	s.MemI = append(s.MemI, i)
-	s.Data = append(s.Data, data...)
+// commit adds the effect of the step just run to the history,
+// dropping the oldest step when full.
+func (m *Arch) commit() {
+	if len(m.history) == m.historySize {
+		m.history = m.history[1:]
+	}
+	m.history = append(m.history, m.effect)
+	m.effect = nil
 }
 
-func (s *Snapshot) includesDevice(i int, data MIXBytes) {
-	s.DeviceI = append(s.DeviceI, i)
-	s.Data = append(s.Data, data...)
+// StepBack undoes the last step.
+func (m *Arch) StepBack() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stepBack()
 }
 
-type History []*Snapshot
+func (m *Arch) stepBack() error {
+	if len(m.history) == 0 {
+		return ErrNoHistory
+	}
+	e := m.history[len(m.history)-1]
+	m.history = m.history[:len(m.history)-1]
+	for i := len(e.undo) - 1; 0 <= i; i-- {
+		e.undo[i]()
+	}
+	for i := len(e.cells) - 1; 0 <= i; i-- {
+		m.poke(e.cells[i].address, e.cells[i].old)
+	}
+	for _, us := range e.units {
+		us.u.ready, us.u.notify = us.ready, us.notify
+	}
+	for i := range e.R {
+		m.R[i].w = e.R[i]
+	}
+	m.PC, m.Time, m.Executed, m.State = e.PC, e.Time, e.Executed, e.State
+	m.OverflowToggle, m.Control, m.pending = e.OverflowToggle, e.Control, e.pending
+	m.SetComparisons(e.Less, e.Equal, e.Greater)
+	return nil
+}
 
-// really just interpreting
-func compile(src io.Reader) ([]Instruction, error) {
-	program := make([]Instruction, 0, 20)
-	line := bufio.NewScanner(src)
-	for line.Scan() {
-		inst, err := ParseInst(line.Text())
-		if err != nil {
-			return nil, err
+// RunBackTo steps back until PC is address, before the instruction
+// there last ran. If history runs out first, it returns ErrNoHistory
+// with the machine at the oldest step kept.
+func (m *Arch) RunBackTo(address Word) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for {
+		if err := m.stepBack(); err != nil {
+			return err
+		}
+		if m.PC == address {
+			return nil
 		}
-		program = append(program, inst)
 	}
-	return program, line.Err()
 }
 
-// Note: Instructions aren't actually loaded in machine memory
-func Run(m *MIXArch, src io.Reader) (History, error) {
-	program, err := compile(src)
-	if err != nil {
-		return nil, err
+// Recorded returns how many steps StepBack can undo.
+func (m *Arch) Recorded() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.history)
+}
+
+// undoStorage returns how to undo an IN (out false) or OUT (out true)
+// on s, or an IOC, which only moves a tape.
+func undoStorage(s *Storage, out bool, x Word) func() {
+	pos, n := s.pos, len(s.Blocks)
+	i := pos
+	if s.disk {
+		i = x
 	}
-	history := make(History, len(program))
-	for timer, pos, entry := 0, 0, m.PC; pos < len(program); {
-		inst := program[m.PC-entry]
-		// TODO:: check if inst is IO for timing implications
-		timer += inst.Duration()
-		effect := m.Exec(inst)
-		history = append(history, effect)
+	var old []Word
+	if out && 0 <= i && i < Word(n) {
+		old = append([]Word(nil), s.Blocks[i]...)
+	}
+	return func() {
+		if old != nil {
+			copy(s.Blocks[i], old)
+		}
+		s.Blocks, s.pos = s.Blocks[:n], pos
 	}
-	return history, nil
 }
-*/
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStepBack(t *testing.T) {
+	tape := NewTape()
+	m := NewMachine(WithHistory(100), WithInterrupts(), WithDevice(TAPE0, tape, 5))
+	copy(m.Mem, []Word{
+		composeInst(3000, 0, 2, C_ADDR_TRANSFER+I1), // ENT1 3000
+		composeInst(1, 0, 0, C_ADDR_TRANSFER),       // INCA 1
+		composeInst(1000, 0, 5, C_ST),               // STA 1000
+		composeInst(1000, 0, 2, C_MOVE),             // MOVE 1000(2)
+		composeInst(1000, 0, TAPE0, C_IO+3),         // OUT 1000(0)
+		composeInst(1, 0, 0, C_JMP),                 // JMP 1
+	})
+	m.Write(-12, composeInst(0, 0, 9, C_SPECIAL)) // INT
+	m.Write(-11, 7)                               // timer
+
+	type state struct {
+		Snapshot
+		Blocks [][]Word
+	}
+	now := func() state {
+		s := state{Snapshot: m.Snapshot()}
+		for _, b := range tape.Blocks {
+			s.Blocks = append(s.Blocks, append([]Word(nil), b...))
+		}
+		return s
+	}
+	var states []state
+	for i := 0; i < 30; i++ {
+		states = append(states, now())
+		if err := m.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := len(states) - 1; 0 <= i; i-- {
+		if err := m.StepBack(); err != nil {
+			t.Fatal(err)
+		}
+		if got := now(); !reflect.DeepEqual(states[i], got) {
+			t.Fatalf("step %d: want\n%+v\ngot\n%+v", i, states[i].Snapshot.R, got.Snapshot.R)
+		}
+	}
+	if err := m.StepBack(); err != ErrNoHistory {
+		t.Errorf("want %v, got %v", ErrNoHistory, err)
+	}
+
+	for i := 0; i < 12; i++ {
+		m.Step()
+	}
+	if err := m.RunBackTo(3); err != nil || m.PC != 3 || m.R[I1].w != 3002 || m.Read(3002) != 0 {
+		t.Errorf("want PC 3 before the second MOVE to 3002, got %v, rI1 %v, %v", m.PC, m.R[I1].w, err)
+	}
+
+	m = NewMachine(WithHistory(5))
+	for i := 0; i < 10; i++ {
+		m.Step()
+	}
+	if m.Recorded() != 5 {
+		t.Errorf("want 5 steps kept, got %v", m.Recorded())
+	}
+	if err := m.RunBackTo(100); err != ErrNoHistory || m.PC != 5 {
+		t.Errorf("want %v at 5, got %v at %v", ErrNoHistory, err, m.PC)
+	}
+}
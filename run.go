@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// State is the run state of a machine.
+type State int
+
+const (
+	Halted     State = iota // stopped, either not started yet or by HLT
+	Running                 // fetching and executing instructions
+	Faulted                 // stopped by an error, see the error returned by Step
+	WaitingIO               // waited for a busy unit, its IN, OUT or IOC runs next
+	Paused                  // stopped by Pause or a canceled context, Run continues
+	OverBudget              // stopped by the limits of WithBudget
+)
+
+func (s State) String() string {
+	switch s {
+	case Halted:
+		return "halted"
+	case Running:
+		return "running"
+	case Faulted:
+		return "faulted"
+	case WaitingIO:
+		return "waiting on I/O"
+	case Paused:
+		return "paused"
+	case OverBudget:
+		return "over budget"
+	}
+	return "unknown"
+}
+
+var ErrPCRange = errors.New("step: PC outside of memory")
+
+// Step fetches the instruction at PC, advances PC past it and executes it.
+// Jumps overwrite the advanced PC, so they take effect on the next Step.
+// A pending interrupt is taken first when in normal state.
+// Step leaves the machine Running, or Halted by HLT, in which case the next
+// Step starts it again at the instruction after the HLT, as Run does.
+// An IN, OUT or IOC on a busy unit takes a Step of its own: the machine
+// waits for the unit and is left WaitingIO with PC at the instruction.
+// On a fault, PC is left at the faulting instruction, the machine is
+// Faulted and the *Fault is returned.
+// A watchpoint hit returns a *Stop, after the instruction.
+func (m *Arch) Step() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.step()
+}
+
+func (m *Arch) step() error {
+	if 0 < m.historySize || m.trace != nil {
+		m.record()
+		defer func() { m.effect = nil }()
+	}
+	m.State = Running
+	if 0 < len(m.watches) {
+		m.stop = nil
+	}
+	if m.pending && !m.Control {
+		m.interrupt()
+	}
+	pc := m.PC
+	if !m.inRange(pc) {
+		m.State = Faulted
+		return m.fault(ErrPCRange, pc, 0)
+	}
+	m.PC++
+	d := m.fetch(pc)
+	if m.effect != nil {
+		m.effect.at, m.effect.inst = pc, d.inst
+	}
+	err := m.execAt(pc, d)
+	if err != nil && err != errWaited {
+		m.PC, m.State = pc, Faulted
+		return err
+	}
+	if err == errWaited {
+		m.PC, m.State = pc, WaitingIO
+	} else if m.trace != nil {
+		m.traceStep()
+	}
+	if 0 < m.historySize {
+		m.commit()
+	}
+	if 0 < len(m.watches) {
+		if s := m.stop; s != nil {
+			s.PC = pc
+			return s
+		}
+	}
+	return nil
+}
+
+var ErrBudget = errors.New("run: budget exceeded")
+
+// BudgetExceeded is the error Run returns when the machine reaches a
+// limit set by WithBudget, with the state it was left in. It wraps ErrBudget.
+type BudgetExceeded struct {
+	Summary
+	PC       Word // the instruction that would have run next
+	Snapshot Snapshot
+}
+
+func (b *BudgetExceeded) Error() string {
+	return fmt.Sprintf("%v at %d after %v", ErrBudget, b.PC, b.Summary)
+}
+
+func (b *BudgetExceeded) Unwrap() error {
+	return ErrBudget
+}
+
+// WithBudget limits Run to executing instructions in all and to
+// time units u of machine time, a limit of 0 being none. Run stops
+// once either is reached, leaving the machine OverBudget. The last
+// instruction may take the time past its limit.
+func WithBudget(instructions, time Word) Option {
+	return func(m *Arch) { m.maxExecuted, m.maxTime = instructions, time }
+}
+
+// overBudget reports whether m has reached a limit set by WithBudget.
+func (m *Arch) overBudget() bool {
+	return 0 < m.maxExecuted && m.maxExecuted <= m.Executed ||
+		0 < m.maxTime && m.maxTime <= m.Time
+}
+
+// yield is how many instructions Run executes between looks at its context,
+// letting Snapshot and Resume in as well. It looks once before the first.
+const yield = 256
+
+// Run steps from PC until the machine halts or faults, or ctx is done,
+// which leaves it Paused and returns ctx.Err(). Running out of a budget,
+// see WithBudget, returns a *BudgetExceeded. Breakpoints and
+// watchpoints also leave it Paused, returning a *Stop; the breakpoint
+// at PC is passed over when Run starts. While Run goes, other goroutines
+// may only use Pause, Resume, Snapshot, Interrupt and the breakpoint methods.
+func (m *Arch) Run(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.State = Running
+	if ctx.Err() != nil {
+		m.State = Paused
+		return ctx.Err()
+	}
+	for n := 1; m.State == Running || m.State == WaitingIO; n++ {
+		if m.pause.Load() {
+			if err := m.paused(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+		if m.overBudget() {
+			m.State = OverBudget
+			return &BudgetExceeded{m.Summary(), m.PC, m.snapshot()}
+		}
+		if 1 < n && m.State != WaitingIO {
+			if s := m.breakpoint(); s != nil {
+				m.State = Paused
+				return s
+			}
+		}
+		if err := m.step(); err != nil {
+			if _, ok := err.(*Stop); ok {
+				m.State = Paused
+			}
+			return err
+		}
+		if n%yield == 0 {
+			m.mu.Unlock()
+			m.mu.Lock()
+			if ctx.Err() != nil {
+				m.State = Paused
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
+
+// paused waits, unlocked, for Resume or for ctx to be done.
+func (m *Arch) paused(ctx context.Context) error {
+	m.State = Paused
+	resume := make(chan struct{})
+	m.resume = resume
+	m.mu.Unlock()
+	var err error
+	select {
+	case <-resume:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	m.mu.Lock()
+	if err == nil {
+		m.State = Running
+	}
+	return err
+}
+
+// Pause stops Run before its next instruction.
+func (m *Arch) Pause() {
+	m.pause.Store(true)
+}
+
+// Resume lets a paused Run go on.
+func (m *Arch) Resume() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pause.Store(false)
+	if m.resume != nil {
+		close(m.resume)
+		m.resume = nil
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	m := NewMachine()
+	program := []Word{
+		composeInst(100, 0, 5, C_LD),    // LDA 100
+		composeInst(101, 0, 5, C_ADD),   // ADD 101
+		composeInst(102, 0, 5, C_ST),    // STA 102
+		composeInst(0, 0, 2, C_SPECIAL), // HLT
+		composeInst(102, 0, 5, 33),      // STZ 102, never reached
+	}
+	copy(m.Mem, program)
+	m.Write(100, 30)
+	m.Write(101, 12)
+	if err := m.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if m.State != Halted || m.PC != 4 {
+		t.Errorf("want halted at 4, got %v at %v", m.State, m.PC)
+	}
+	if got := m.Read(102); got != 42 {
+		t.Error(wordDiff(42, got))
+	}
+}
+
+func TestStepState(t *testing.T) {
+	m := NewMachine()
+	copy(m.Mem, []Word{
+		composeInst(0, 0, 2, C_SPECIAL), // HLT
+		composeInst(0, 0, 0, 0),         // NOP
+	})
+	for _, want := range []State{Halted, Running} {
+		if err := m.Step(); err != nil || m.State != want {
+			t.Errorf("want %v, got %v and %v", want, m.State, err)
+		}
+	}
+}
+
+func TestStepPCRange(t *testing.T) {
+	m := NewMachine()
+	m.PC = Word(len(m.Mem))
+	if err := m.Step(); !errors.Is(err, ErrPCRange) || m.State != Faulted {
+		t.Errorf("want %v and faulted, got %v and %v", ErrPCRange, err, m.State)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	tests := []struct {
+		instructions, time Word
+		executed, pc, a    Word
+	}{
+		{100, 0, 100, 0, 50},
+		{0, 51, 51, 1, 26},
+		{100, 51, 51, 1, 26},
+	}
+	for _, test := range tests {
+		m := NewMachine(WithBudget(test.instructions, test.time))
+		copy(m.Mem, []Word{
+			composeInst(1, 0, 0, C_ADDR_TRANSFER), // INCA 1
+			composeInst(0, 0, 0, C_JMP),           // JMP 0
+		})
+		err := m.Run(context.Background())
+		var b *BudgetExceeded
+		if !errors.As(err, &b) || !errors.Is(err, ErrBudget) {
+			t.Fatalf("want %v, got %v", ErrBudget, err)
+		}
+		if m.State != OverBudget || m.Executed != test.executed || m.PC != test.pc {
+			t.Errorf("%+v: want over budget at %d after %d, got %v at %d after %d",
+				test, test.pc, test.executed, m.State, m.PC, m.Executed)
+		}
+		if b.Instructions != test.executed || b.PC != test.pc || b.Snapshot.R[A] != test.a {
+			t.Errorf("%+v: got %v with rA %d", test, err, b.Snapshot.R[A])
+		}
+	}
+}
+
+// TestByteSizes runs the same program with binary and decimal bytes.
+func TestByteSizes(t *testing.T) {
+	for _, b := range []ByteSize{Binary, Decimal} {
+		m := NewMachine(WithByteSize(b), WithFloatingPoint())
+		copy(m.Mem, []Word{
+			b.inst(5, 0, 2, C_ADDR_TRANSFER+I1), // ENT1 5
+			b.inst(0, 0, 2, C_ADDR_TRANSFER),    // ENTA 0
+			b.inst(100, 1, 5, C_ADD),            // ADD 100,1
+			b.inst(1, 0, 1, C_ADDR_TRANSFER+I1), // DEC1 1
+			b.inst(2, 0, 2, C_JREG+I1),          // J1P 2
+			b.inst(200, 0, 37, C_ST),            // STA 200(4:5)
+			b.inst(200, 0, 37, C_LD+X),          // LDX 200(4:5)
+			b.inst(0, 0, 1, C_SPECIAL),          // CHAR
+			b.inst(0, 0, 2, C_SPECIAL),          // HLT
+		})
+		copy(m.Mem[101:], []Word{10, 20, 30, 40, 50})
+		if err := m.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if s, _ := b.decodeWord(m.R[X].w); s != "00150" {
+			t.Errorf("byte size %d: want 00150 in rX, got %q", b, s)
+		}
+		if m.Read(200) != b.word(0, 0, 0, 150/Word(b), 150%Word(b)) {
+			t.Errorf("byte size %d: want 150 in 200(4:5), got %s", b, b.view(m.Read(200)))
+		}
+	}
+}
+
+// TestTiming runs Program M of TAOCP 1.3.2, which finds the maximum of
+// X[1..n] in (5n + 3A + 5)u, A the number of times the maximum changes.
+func TestTiming(t *testing.T) {
+	m := NewMachine()
+	program := []Word{
+		composeInst(5, 0, 2, C_ADDR_TRANSFER+I1), // ENT1 5
+		composeInst(3, 0, 0, C_JMP),              // JMP MAXIMUM
+		composeInst(0, 0, 2, C_SPECIAL),          // HLT
+		composeInst(12, 0, 2, 32),                // MAXIMUM STJ EXIT
+		composeInst(0, 1, 2, C_ADDR_TRANSFER+I3), // ENT3 0,1
+		composeInst(8, 0, 0, C_JMP),              // JMP CHANGEM
+		composeInst(1000, 3, 5, C_CMP),           // LOOP CMPA X,3
+		composeInst(10, 0, 7, C_JMP),             // JGE *+3
+		composeInst(0, 3, 2, C_ADDR_TRANSFER+I2), // CHANGEM ENT2 0,3
+		composeInst(1000, 3, 5, C_LD),            // LDA X,3
+		composeInst(1, 0, 1, C_ADDR_TRANSFER+I3), // DEC3 1
+		composeInst(6, 0, 2, C_JREG+I3),          // J3P LOOP
+		composeInst(0, 0, 0, C_JMP),              // EXIT JMP *
+	}
+	copy(m.Mem, program)
+	copy(m.Mem[1001:], []Word{5, 1, 4, 1, 3}) // A = 2
+	if err := m.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if m.R[A].w != 5 || m.R[I2].w != 1 {
+		t.Errorf("want max 5 at 1, got %v at %v", m.R[A].w, m.R[I2].w)
+	}
+	n, A := Word(5), Word(2)
+	want := Summary{
+		Time:         12 + 5*n + 3*A + 5, // ENT1, JMP and HLT take 12u
+		Instructions: 3 + 4 + 2*(n-1) + 2*(A+1) + 2*n,
+	}
+	if got := m.Summary(); got != want {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	for _, test := range []struct {
+		Inst, Time Word
+	}{
+		{composeInst(0, 0, 0, 0), 1},             // NOP
+		{composeInst(1000, 0, 5, C_MUL), 10},     // MUL 1000
+		{composeInst(1000, 0, 5, C_DIV), 12},     // DIV 1000
+		{composeInst(1000, 0, 3, C_MOVE), 7},     // MOVE 1000(3)
+		{composeInst(1000, 0, 6, C_MUL), 9},      // FMUL 1000
+		{composeInst(1000, 0, 6, C_DIV), 11},     // FDIV 1000
+		{composeInst(0, 0, 7, C_SPECIAL), 3},     // FIX
+		{composeInst(1000, 0, 5, C_ST+X-A), 2},   // STX 1000
+		{composeInst(1000, 0, 0, C_JREG+X-A), 1}, // JXN 1000
+	} {
+		m := NewMachine(WithFloatingPoint())
+		m.Write(1000, composeWord(1, 0, 0, 0, 1))
+		if err := m.Exec(test.Inst); err != nil {
+			t.Fatal(err)
+		}
+		if m.Time != test.Time {
+			t.Errorf("%v: want %vu, got %vu", test.Inst.view(), test.Time, m.Time)
+		}
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	m := NewMachine()
+	copy(m.Mem, []Word{
+		composeInst(1, 0, 0, C_ADDR_TRANSFER), // INCA 1
+		composeInst(0, 0, 0, C_JMP),           // JMP 0
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := m.Run(ctx); err != context.DeadlineExceeded || m.State != Paused {
+		t.Fatalf("want %v and paused, got %v and %v", context.DeadlineExceeded, err, m.State)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- m.Run(ctx) }()
+	waitFor := func(cond func(Snapshot) bool) Snapshot {
+		for {
+			if s := m.Snapshot(); cond(s) {
+				return s
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitFor(func(s Snapshot) bool { return s.State == Running })
+	m.Pause()
+	paused := waitFor(func(s Snapshot) bool { return s.State == Paused })
+	time.Sleep(5 * time.Millisecond)
+	if s := m.Snapshot(); s.Executed != paused.Executed {
+		t.Errorf("want no progress while paused, got %v then %v", paused.Executed, s.Executed)
+	}
+	m.Resume()
+	waitFor(func(s Snapshot) bool { return paused.Executed < s.Executed })
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("want %v, got %v", context.Canceled, err)
+	}
+
+	executed := m.Executed
+	if err := m.Run(ctx); err != context.Canceled || m.Executed != executed || m.State != Paused {
+		t.Errorf("canceled: want %v, paused after %v, got %v, %v after %v", context.Canceled, executed, err, m.State, m.Executed)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// A saved machine is a JSON object, version 1:
+//
+//	version          1
+//	byteSize         64 or 100
+//	floatingPoint, binaryExtensions, interrupts, strict
+//	                 the extensions and mode, see the Options
+//	registers        rA, rI1-rI6, rX, rJ as {"value", "width"},
+//	                 width in bytes
+//	memory           all of memory, from location 0
+//	controlMemory    locations -1, -2, ... from index 1, with interrupts
+//	pc, state, time, executed, overflow, less, equal, greater,
+//	control, pending the rest of Arch
+//	units            by unit number: latency, ready and notify, and for
+//	                 tapes and disks "storage": its blocks, pos and disk
+//
+// Words, such as register values and memory, are numbers, -0 written as -0.
+// Character devices keep no state of their own, they are attached
+// again with the options given to Restore.
+const saveVersion = 1
+
+var (
+	ErrSaveVersion = errors.New("restore: unknown save version")
+	ErrSaveFormat  = errors.New("restore: saved machine is inconsistent")
+)
+
+type savedRegister struct {
+	Value jsonWord `json:"value"`
+	Width Word     `json:"width"`
+}
+
+type savedStorage struct {
+	Blocks [][]jsonWord `json:"blocks"`
+	Pos    Word         `json:"pos"`
+	Disk   bool         `json:"disk"`
+}
+
+type savedUnit struct {
+	Latency Word          `json:"latency"`
+	Ready   Word          `json:"ready"`
+	Notify  bool          `json:"notify"`
+	Storage *savedStorage `json:"storage,omitempty"`
+}
+
+type savedMachine struct {
+	Version          int                `json:"version"`
+	ByteSize         ByteSize           `json:"byteSize"`
+	FloatingPoint    bool               `json:"floatingPoint"`
+	BinaryExtensions bool               `json:"binaryExtensions"`
+	Interrupts       bool               `json:"interrupts"`
+	Strict           bool               `json:"strict"`
+	Registers        []savedRegister    `json:"registers"`
+	Memory           []jsonWord         `json:"memory"`
+	ControlMemory    []jsonWord         `json:"controlMemory,omitempty"`
+	PC               Word               `json:"pc"`
+	State            State              `json:"state"`
+	Time             Word               `json:"time"`
+	Executed         Word               `json:"executed"`
+	Overflow         bool               `json:"overflow"`
+	Less             bool               `json:"less"`
+	Equal            bool               `json:"equal"`
+	Greater          bool               `json:"greater"`
+	Control          bool               `json:"control"`
+	Pending          bool               `json:"pending"`
+	Units            map[Word]savedUnit `json:"units"`
+}
+
+// Save writes the state of m to w. It is safe to call while Run goes.
+func (m *Arch) Save(w io.Writer) error {
+	m.mu.Lock()
+	s := savedMachine{
+		Version:          saveVersion,
+		ByteSize:         m.b,
+		FloatingPoint:    m.floatingPoint,
+		BinaryExtensions: m.binaryExt,
+		Interrupts:       m.interrupts,
+		Strict:           m.strict,
+		Memory:           jsonWords(m.Mem),
+		ControlMemory:    jsonWords(m.control),
+		PC:               m.PC,
+		State:            m.State,
+		Time:             m.Time,
+		Executed:         m.Executed,
+		Overflow:         m.OverflowToggle,
+		Control:          m.Control,
+		Pending:          m.pending,
+		Units:            make(map[Word]savedUnit),
+	}
+	s.Less, s.Equal, s.Greater = m.Comparisons()
+	for _, r := range m.R {
+		s.Registers = append(s.Registers, savedRegister{jsonWord(r.w), r.len})
+	}
+	for n, u := range m.units {
+		su := savedUnit{Latency: u.latency, Ready: u.ready, Notify: u.notify}
+		if st, ok := u.dev.(*Storage); ok {
+			blocks := make([][]jsonWord, len(st.Blocks))
+			for i, b := range st.Blocks {
+				blocks[i] = jsonWords(b)
+			}
+			su.Storage = &savedStorage{blocks, st.pos, st.disk}
+		}
+		s.Units[n] = su
+	}
+	m.mu.Unlock()
+	return json.NewEncoder(w).Encode(&s)
+}
+
+// Restore reads a machine written by Save. opts are applied after the
+// saved configuration, to attach the character devices again.
+// Tapes and disks are restored with their contents.
+func Restore(r io.Reader, opts ...Option) (*Arch, error) {
+	var s savedMachine
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+	if s.Version != saveVersion {
+		return nil, ErrSaveVersion
+	}
+	if s.ByteSize != Binary && s.ByteSize != Decimal {
+		return nil, ErrSaveFormat
+	}
+	if n := Word(len(s.Memory)); n < 1 || s.ByteSize.pow(2) < n {
+		return nil, ErrSaveFormat
+	}
+	config := []Option{WithByteSize(s.ByteSize), WithMemorySize(Word(len(s.Memory)))}
+	if s.FloatingPoint {
+		config = append(config, WithFloatingPoint())
+	}
+	if s.BinaryExtensions {
+		config = append(config, WithBinaryExtensions())
+	}
+	if s.Interrupts {
+		config = append(config, WithInterrupts())
+	}
+	if s.Strict {
+		config = append(config, WithStrict())
+	}
+	m := NewMachine(append(config, opts...)...)
+	if len(s.Registers) != len(m.R) || len(s.ControlMemory) != len(m.control) {
+		return nil, ErrSaveFormat
+	}
+	for i, sr := range s.Registers {
+		if sr.Width < 1 || WORDSIZE < sr.Width {
+			return nil, ErrSaveFormat
+		}
+		m.R[i] = &bitslice{Word(sr.Value), 0, sr.Width, m.b}
+	}
+	copy(m.Mem, words(s.Memory))
+	copy(m.control, words(s.ControlMemory))
+	m.PC, m.State, m.Time, m.Executed = s.PC, s.State, s.Time, s.Executed
+	m.OverflowToggle, m.Control, m.pending = s.Overflow, s.Control, s.Pending
+	m.SetComparisons(s.Less, s.Equal, s.Greater)
+	for n, su := range s.Units {
+		u := m.units[n]
+		if su.Storage != nil {
+			if StorageBlocks < len(su.Storage.Blocks) {
+				return nil, ErrSaveFormat
+			}
+			blocks := make([][]Word, len(su.Storage.Blocks))
+			for i, b := range su.Storage.Blocks {
+				blocks[i] = words(b)
+			}
+			st := &Storage{blocks, su.Storage.Pos, su.Storage.Disk}
+			if u == nil {
+				u = &unit{}
+				m.units[n] = u
+			}
+			u.dev = st
+		}
+		if u == nil {
+			continue // a character device not attached again
+		}
+		u.latency, u.ready, u.notify = su.Latency, su.Ready, su.Notify
+	}
+	return m, nil
+}
+
+// jsonWords and words copy words to and from a saved file.
+
+func jsonWords(ws []Word) []jsonWord {
+	js := make([]jsonWord, len(ws))
+	for i, w := range ws {
+		js[i] = jsonWord(w)
+	}
+	return js
+}
+
+func words(js []jsonWord) []Word {
+	ws := make([]Word, len(js))
+	for i, j := range js {
+		ws[i] = Word(j)
+	}
+	return ws
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSaveRestore(t *testing.T) {
+	var out bytes.Buffer
+	printer := WithDevice(LINE_PRINTER, NewText(24, nil, &out), 50)
+	m := NewMachine(WithInterrupts(), WithFloatingPoint(), WithDevice(TAPE1, NewTape(), 10), printer)
+	copy(m.Mem, []Word{
+		composeInst(2000, 0, TAPE1, C_IO+3),        // OUT 2000(1)
+		composeInst(1, 0, 0, C_ADDR_TRANSFER+I3),   // INC3 1
+		composeInst(2000, 0, LINE_PRINTER, C_IO+3), // OUT 2000(18)
+		composeInst(1, 0, 0, C_JMP),                // JMP 1
+	})
+	m.Write(2000, composeWord(8, 5, 13, 13, 16))  // HELLO
+	m.Write(-12, composeInst(0, 0, 9, C_SPECIAL)) // INT
+	m.R[X].w, m.OverflowToggle = negZero, true
+	m.SetComparisons(true, false, false)
+	for i := 0; i < 7; i++ {
+		if err := m.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var saved bytes.Buffer
+	if err := m.Save(&saved); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(saved.Bytes(), []byte(`"value":-0,`)) {
+		t.Errorf("want rX saved as -0, got\n%s", saved.Bytes())
+	}
+	r, err := Restore(bytes.NewReader(saved.Bytes()), printer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m.Snapshot(), r.Snapshot()) {
+		t.Fatalf("restored machine differs\n%+v\n%+v", m.Snapshot().R, r.Snapshot().R)
+	}
+	if !reflect.DeepEqual(m.units[TAPE1].dev, r.units[TAPE1].dev) || *m.units[LINE_PRINTER] != *r.units[LINE_PRINTER] {
+		t.Error("restored units differ")
+	}
+	for i := 0; i < 10; i++ {
+		if err1, err2 := m.Step(), r.Step(); err1 != nil || err2 != nil {
+			t.Fatal(err1, err2)
+		}
+	}
+	if !reflect.DeepEqual(m.Snapshot(), r.Snapshot()) {
+		t.Error("restored machine runs differently")
+	}
+
+	bad := strings.Replace(saved.String(), `"version":1`, `"version":2`, 1)
+	if _, err := Restore(strings.NewReader(bad)); err != ErrSaveVersion {
+		t.Errorf("want %v, got %v", ErrSaveVersion, err)
+	}
+}
+
+// TestSaveWhileRunning saves a machine writing to tape as it runs,
+// for go test -race.
+func TestSaveWhileRunning(t *testing.T) {
+	m := NewMachine(WithDevice(TAPE0, NewTape(), 0))
+	copy(m.Mem, []Word{
+		composeInst(1, 0, 0, C_ADDR_TRANSFER+I1), // INC1 1
+		composeInst(2000, 0, 5, C_ST+I1),         // ST1 2000
+		composeInst(2000, 0, TAPE0, C_IO+3),      // OUT 2000(0)
+		composeInst(0, 0, TAPE0, C_IO+1),         // IOC 0(0)
+		composeInst(0, 0, 0, C_JMP),              // JMP 0
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- m.Run(ctx) }()
+	for i := 0; i < 20; i++ {
+		if err := m.Save(&bytes.Buffer{}); err != nil {
+			t.Error(err)
+		}
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("want %v, got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+// Snapshot is a copy of the state of a machine at one moment.
+type Snapshot struct {
+	R                    [J + 1]Word // rA, rI1-rI6, rX, rJ
+	Mem                  []Word
+	ControlMem           []Word // -1, -2, ... at index 1, 2, ..., nil without interrupts
+	PC                   Word
+	State                State
+	Time, Executed       Word
+	OverflowToggle       bool
+	Less, Equal, Greater bool
+	Control              bool
+}
+
+// Snapshot copies the registers, memory and indicators of m.
+// It is safe to call while Run goes in another goroutine.
+func (m *Arch) Snapshot() Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.snapshot()
+}
+
+func (m *Arch) snapshot() Snapshot {
+	s := Snapshot{
+		Mem:            append([]Word(nil), m.Mem...),
+		PC:             m.PC,
+		State:          m.State,
+		Time:           m.Time,
+		Executed:       m.Executed,
+		OverflowToggle: m.OverflowToggle,
+		Control:        m.Control,
+	}
+	if m.control != nil {
+		s.ControlMem = append([]Word(nil), m.control...)
+	}
+	for i := range s.R {
+		s.R[i] = m.R[i].w
+	}
+	s.Less, s.Equal, s.Greater = m.Comparisons()
+	return s
+}
@@ -0,0 +1,17 @@
+package main
+
+import "fmt"
+
+// Summary reports what a machine has done so far.
+type Summary struct {
+	Time         Word // elapsed time in units u
+	Instructions Word // instructions executed
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("%d instructions in %du", s.Instructions, s.Time)
+}
+
+func (m *Arch) Summary() Summary {
+	return Summary{m.Time, m.Executed}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// A trace has one JSON object per line for each instruction executed:
+//
+//	{"step":12,"pc":3,"inst":262470,"disasm":"STA 1000","m":1000,
+//	 "registers":[{"register":"A","old":0,"new":5}],
+//	 "memory":[{"address":1000,"old":0,"new":5}],"cycles":2,"time":21}
+//
+// step counts instructions, cycles is the time the instruction took
+// and time the machine time after it, both in u. registers and memory
+// list what changed, memory also the register saves of an interrupt.
+// Words are numbers, -0 written as -0.
+
+// jsonWord is a Word that keeps the sign of -0 in JSON.
+type jsonWord Word
+
+func (w jsonWord) MarshalJSON() ([]byte, error) {
+	if Word(w) == negZero {
+		return []byte("-0"), nil
+	}
+	return strconv.AppendInt(nil, int64(w), 10), nil
+}
+
+func (w *jsonWord) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("-0")) {
+		*w = jsonWord(negZero)
+		return nil
+	}
+	return json.Unmarshal(b, (*int64)(w))
+}
+
+type regChange struct {
+	Register string   `json:"register"`
+	Old      jsonWord `json:"old"`
+	New      jsonWord `json:"new"`
+}
+
+type cellChange struct {
+	Address Word     `json:"address"`
+	Old     jsonWord `json:"old"`
+	New     jsonWord `json:"new"`
+}
+
+type traceRecord struct {
+	Step      Word         `json:"step"`
+	PC        Word         `json:"pc"`
+	Inst      jsonWord     `json:"inst"`
+	Disasm    string       `json:"disasm"`
+	M         Word         `json:"m"`
+	Registers []regChange  `json:"registers,omitempty"`
+	Memory    []cellChange `json:"memory,omitempty"`
+	Cycles    Word         `json:"cycles"`
+	Time      Word         `json:"time"`
+}
+
+var regNames = [J + 1]string{"A", "I1", "I2", "I3", "I4", "I5", "I6", "X", "J"}
+
+// WithTrace writes a record of each instruction executed to w.
+// If writing fails, tracing stops, see TraceErr.
+func WithTrace(w io.Writer) Option {
+	return func(m *Arch) { m.trace = json.NewEncoder(w) }
+}
+
+// TraceErr returns the error that stopped the trace, if any.
+func (m *Arch) TraceErr() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.traceErr
+}
+
+// traceStep writes the record of the step in m.effect.
+func (m *Arch) traceStep() {
+	e := m.effect
+	r := traceRecord{
+		Step:   m.Executed,
+		PC:     e.at,
+		Inst:   jsonWord(e.inst),
+		Disasm: m.b.disassemble(e.inst),
+		M:      e.M,
+		Cycles: m.Time - e.Time,
+		Time:   m.Time,
+	}
+	for i, old := range e.R {
+		if w := m.R[i].w; w != old {
+			r.Registers = append(r.Registers, regChange{regNames[i], jsonWord(old), jsonWord(w)})
+		}
+	}
+	seen := make(map[Word]bool, len(e.cells))
+	for _, c := range e.cells {
+		if !seen[c.address] {
+			seen[c.address] = true
+			r.Memory = append(r.Memory, cellChange{c.address, jsonWord(c.old), jsonWord(m.peek(c.address))})
+		}
+	}
+	if err := m.trace.Encode(&r); err != nil {
+		m.trace, m.traceErr = nil, err
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	var out bytes.Buffer
+	m := NewMachine(WithTrace(&out))
+	copy(m.Mem, []Word{
+		composeInst(5, 0, 2, C_ADDR_TRANSFER),  // ENTA 5
+		composeInst(1000, 0, 5, C_ST),          // STA 1000
+		-composeInst(0, 0, 2, C_ADDR_TRANSFER), // ENTA -0
+		composeInst(0, 0, 2, C_SPECIAL),        // HLT
+	})
+	if err := m.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []traceRecord{
+		{Step: 1, PC: 0, Inst: jsonWord(m.Mem[0]), Disasm: "ENTA 5", M: 5, Registers: []regChange{{"A", 0, 5}}, Cycles: 1, Time: 1},
+		{Step: 2, PC: 1, Inst: jsonWord(m.Mem[1]), Disasm: "STA 1000", M: 1000, Memory: []cellChange{{1000, 0, 5}}, Cycles: 2, Time: 3},
+		{Step: 3, PC: 2, Inst: jsonWord(m.Mem[2]), Disasm: "ENTA -0", Registers: []regChange{{"A", 5, jsonWord(negZero)}}, Cycles: 1, Time: 4},
+		{Step: 4, PC: 3, Inst: jsonWord(m.Mem[3]), Disasm: "HLT 0", Cycles: 10, Time: 14},
+	}
+	if !bytes.Contains(out.Bytes(), []byte(`"new":-0}`)) {
+		t.Errorf("want -0 written as -0, got\n%s", out.Bytes())
+	}
+	var got []traceRecord
+	lines := bufio.NewScanner(&out)
+	for lines.Scan() {
+		var r traceRecord
+		if err := json.Unmarshal(lines.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want\n%+v\ngot\n%+v", want, got)
+	}
+	if m.TraceErr() != nil {
+		t.Error(m.TraceErr())
+	}
+}